@@ -29,6 +29,11 @@ type AuditEntry struct {
 	Context string `json:"context"`
 }
 
+type auditContextEntry struct {
+	goadt.GenericFields
+	Context
+}
+
 var auditService *goadt.AuditSvc
 
 func Init(restPort string) error {
@@ -44,10 +49,20 @@ func Init(restPort string) error {
 
 func Log(event auditevent.AuditEvent, req *http.Request, context interface{}) error {
 	logging.Tracef("Audit event %rm with context %ru on request %ru", event, context, req)
-	entry := AuditEntry{
-		GenericFields: goadt.GetAuditBasicFields(req),
-		Context:       fmt.Sprintf("%v", context),
+
+	var entry interface{}
+	if c, ok := context.(Context); ok {
+		entry = auditContextEntry{
+			GenericFields: goadt.GetAuditBasicFields(req),
+			Context:       c,
+		}
+	} else {
+		entry = AuditEntry{
+			GenericFields: goadt.GetAuditBasicFields(req),
+			Context:       fmt.Sprintf("%v", context),
+		}
 	}
+
 	if auditService == nil {
 		logging.Debugf("Audit event without audit service: %ru", entry)
 		return nil
@@ -0,0 +1,10 @@
+package audit
+
+// Context carries structured details of an audited action, which are logged as
+// fields of the event instead of being flattened into a string
+type Context struct {
+	Function string      `json:"function,omitempty"`
+	Endpoint string      `json:"endpoint,omitempty"`
+	OldValue interface{} `json:"old_value,omitempty"`
+	NewValue interface{} `json:"new_value,omitempty"`
+}
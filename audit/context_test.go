@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContextPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		context Context
+		want    string
+	}{
+		{"revisions fetched", Context{Function: "f1"}, `{"function":"f1"}`},
+		{"function rolled back", Context{Function: "f1", NewValue: 3}, `{"function":"f1","new_value":3}`},
+		{"settings changed",
+			Context{Function: "f1", OldValue: map[string]interface{}{"worker_count": 3}, NewValue: map[string]interface{}{"worker_count": 4}},
+			`{"function":"f1","old_value":{"worker_count":3},"new_value":{"worker_count":4}}`},
+		{"credentials fetched", Context{Endpoint: "127.0.0.1:11210"}, `{"endpoint":"127.0.0.1:11210"}`},
+		{"empty", Context{}, `{}`},
+	}
+
+	for _, test := range tests {
+		got, err := json.Marshal(test.context)
+		if err != nil {
+			t.Fatalf("%s: got err %v", test.name, err)
+		}
+
+		if string(got) != test.want {
+			t.Errorf("%s: got %s want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -38,10 +38,12 @@ type EventingProducer interface {
 	CleanupMetadataBucket()
 	ClearEventStats()
 	GetAppCode() string
+	GetAppLog(sz int) ([]string, error)
 	GetDcpEventsRemainingToProcess() uint64
 	GetDebuggerURL() string
 	GetEventingConsumerPids() map[string]int
 	GetEventProcessingStats() map[string]uint64
+	GetEventsProcessedPSec() *EventProcessingStats
 	GetExecutionStats() map[string]interface{}
 	GetFailureStats() map[string]interface{}
 	GetHandlerCode() string
@@ -51,8 +53,10 @@ type EventingProducer interface {
 	GetPlasmaStats() (map[string]interface{}, error)
 	GetSeqsProcessed() map[int]int64
 	GetSourceMap() string
+	GetWorkerStats() map[string]*WorkerStats
 	InternalVbDistributionStats() map[string]string
 	IsEventingNodeAlive(eventingHostPortAddr, nodeUUID string) bool
+	IsProcessingPaused() bool
 	KvHostPorts() []string
 	LenRunningConsumers() int
 	MetadataBucket() string
@@ -78,7 +82,9 @@ type EventingProducer interface {
 	StopProducer()
 	StopRunningConsumers()
 	String() string
+	SubscribeAppLog() (int64, <-chan string)
 	TimerDebugStats() map[int]map[string]interface{}
+	UnsubscribeAppLog(id int64)
 	UpdatePlasmaMemoryQuota(quota int64)
 	VbDcpEventsRemainingToProcess() map[int]int64
 	VbDistributionStatsFromMetadata() map[string]map[string]string
@@ -134,11 +140,13 @@ type EventingSuperSup interface {
 	DeployedAppList() []string
 	GetEventProcessingStats(appName string) map[string]uint64
 	GetAppCode(appName string) string
+	GetAppLog(appName string, sz int) ([]string, error)
 	GetAppState(appName string) int8
 	GetDcpEventsRemainingToProcess(appName string) uint64
 	GetDebuggerURL(appName string) string
 	GetDeployedApps() map[string]string
 	GetEventingConsumerPids(appName string) map[string]int
+	GetEventsProcessedPSec(appName string) *EventProcessingStats
 	GetExecutionStats(appName string) map[string]interface{}
 	GetFailureStats(appName string) map[string]interface{}
 	GetHandlerCode(appName string) string
@@ -146,9 +154,12 @@ type EventingSuperSup interface {
 	GetLcbExceptionsStats(appName string) map[string]uint64
 	GetLocallyDeployedApps() map[string]string
 	GetPlasmaStats(appName string) (map[string]interface{}, error)
+	GetRebalanceStatus(appName string) bool
 	GetSeqsProcessed(appName string) map[int]int64
 	GetSourceMap(appName string) string
+	GetWorkerStats(appName string) map[string]*WorkerStats
 	InternalVbDistributionStats(appName string) map[string]string
+	IsProcessingPaused(appName string) bool
 	NotifyPrepareTopologyChange(ejectNodes, keepNodes []string)
 	PlannerStats(appName string) []*PlannerNodeVbMapping
 	RebalanceStatus() bool
@@ -157,6 +168,8 @@ type EventingSuperSup interface {
 	SignalStartDebugger(appName string)
 	TimerDebugStats(appName string) (map[int]map[string]interface{}, error)
 	SignalStopDebugger(appName string)
+	SubscribeAppLog(appName string) (int64, <-chan string, error)
+	UnsubscribeAppLog(appName string, id int64)
 	VbDcpEventsRemainingToProcess(appName string) map[int]int64
 	VbDistributionStatsFromMetadata(appName string) map[string]map[string]string
 }
@@ -197,6 +210,16 @@ type DebuggerInstanceAddrBlob struct {
 	NodeUUID     string `json:"uuid"`
 }
 
+// WorkerStats captures stats of a single Eventing.Consumer instance
+type WorkerStats struct {
+	DcpEventsRemaining   uint64
+	EventProcessingStats map[string]uint64
+	ExecutionStats       map[string]interface{}
+	FailureStats         map[string]interface{}
+	LatencyStats         map[string]uint64
+	LcbExceptionStats    map[string]uint64
+}
+
 type CompileStatus struct {
 	Language       string `json:"language"`
 	CompileSuccess bool   `json:"compile_success"`
@@ -215,6 +238,7 @@ type PlannerNodeVbMapping struct {
 }
 
 type HandlerConfig struct {
+	BinaryDocuments             bool
 	CheckpointInterval          int
 	CleanupTimers               bool
 	CPPWorkerThrCount           int
@@ -226,12 +250,18 @@ type HandlerConfig struct {
 	FeedbackQueueCap            int64
 	FeedbackReadBufferSize      int
 	FuzzOffset                  int
+	IncludeXattrs               []string    // nil when meta carries only the basic fields
+	KeyFilters                  *KeyFilters // nil when every key is handed to the handler
 	LcbInstCapacity             int
 	LogLevel                    string
+	MaxEventsPerSec             int
+	MaxTimerEventsPerSec        int
+	ProcessingPaused            bool
 	SkipTimerThreshold          int
 	SocketWriteBatchSize        int
 	SocketTimeout               int
 	SourceBucket                string
+	SourceFilter                string // N1QL expression, empty when every document is handed to the handler
 	StatsLogInterval            int
 	StreamBoundary              DcpStreamBoundary
 	TimerProcessingTickInterval int
@@ -0,0 +1,101 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// KeyPattern matches document keys by prefix or by regular expression, exactly one of which is set
+type KeyPattern struct {
+	Prefix string `json:"prefix,omitempty"`
+	Regex  string `json:"regex,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// KeyFilters decides which keys are handed to the handler. A key passes when it matches
+// any include pattern, or when there are none, and matches no exclude pattern.
+type KeyFilters struct {
+	Include []KeyPattern `json:"include,omitempty"`
+	Exclude []KeyPattern `json:"exclude,omitempty"`
+}
+
+// ParseKeyFilters reads key_filters setting as unmarshalled from JSON, compiling its regexes
+func ParseKeyFilters(setting interface{}) (*KeyFilters, error) {
+	data, err := json.Marshal(setting)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	filters := &KeyFilters{}
+	if err = decoder.Decode(filters); err != nil {
+		return nil, fmt.Errorf("must be an object with include and exclude lists of patterns, err: %v", err)
+	}
+
+	for i := range filters.Include {
+		if err = filters.Include[i].compile(); err != nil {
+			return nil, fmt.Errorf("include[%d] %v", i, err)
+		}
+	}
+
+	for i := range filters.Exclude {
+		if err = filters.Exclude[i].compile(); err != nil {
+			return nil, fmt.Errorf("exclude[%d] %v", i, err)
+		}
+	}
+
+	return filters, nil
+}
+
+func (p *KeyPattern) compile() (err error) {
+	if (p.Prefix == "") == (p.Regex == "") {
+		return fmt.Errorf("must have exactly one of prefix and regex")
+	}
+
+	if p.Regex != "" {
+		if p.re, err = regexp.Compile(p.Regex); err != nil {
+			return fmt.Errorf("regex is invalid, err: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (p *KeyPattern) matches(key []byte) bool {
+	if p.re != nil {
+		return p.re.Match(key)
+	}
+	return bytes.HasPrefix(key, []byte(p.Prefix))
+}
+
+// Allows reports whether key passes the filters, nil filters allow every key
+func (f *KeyFilters) Allows(key []byte) bool {
+	if f == nil {
+		return true
+	}
+
+	allowed := len(f.Include) == 0
+	for i := range f.Include {
+		if f.Include[i].matches(key) {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return false
+	}
+
+	for i := range f.Exclude {
+		if f.Exclude[i].matches(key) {
+			return false
+		}
+	}
+
+	return true
+}
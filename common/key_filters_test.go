@@ -0,0 +1,78 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseKeyFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		wantErr bool
+	}{
+		{"empty", `{}`, false},
+		{"prefix and regex", `{"include":[{"prefix":"user::"}],"exclude":[{"regex":"^user::tmp"}]}`, false},
+		{"unknown field", `{"include":[],"other":[]}`, true},
+		{"unknown pattern field", `{"include":[{"suffix":"::tmp"}]}`, true},
+		{"not an object", `["user::"]`, true},
+		{"neither prefix nor regex", `{"include":[{}]}`, true},
+		{"both prefix and regex", `{"exclude":[{"prefix":"a","regex":"b"}]}`, true},
+		{"invalid regex", `{"include":[{"regex":"("}]}`, true},
+	}
+
+	for _, test := range tests {
+		var setting interface{}
+		if err := json.Unmarshal([]byte(test.setting), &setting); err != nil {
+			t.Fatalf("%s: failed to unmarshal setting, err: %v", test.name, err)
+		}
+
+		filters, err := ParseKeyFilters(setting)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: got err %v want err %v", test.name, err, test.wantErr)
+		}
+		if err == nil && filters == nil {
+			t.Errorf("%s: got nil filters", test.name)
+		}
+	}
+}
+
+func TestKeyFiltersAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		key     string
+		want    bool
+	}{
+		{"no patterns", `{}`, "any", true},
+		{"included by prefix", `{"include":[{"prefix":"user::"}]}`, "user::1", true},
+		{"not included", `{"include":[{"prefix":"user::"}]}`, "order::1", false},
+		{"included by any pattern", `{"include":[{"prefix":"user::"},{"regex":"^order::[0-9]+$"}]}`, "order::1", true},
+		{"regex not anchored", `{"include":[{"regex":"::tmp"}]}`, "user::tmp::1", true},
+		{"excluded", `{"exclude":[{"prefix":"_sync"}]}`, "_sync:rev", false},
+		{"not excluded", `{"exclude":[{"prefix":"_sync"}]}`, "user::1", true},
+		{"exclude wins over include", `{"include":[{"prefix":"user::"}],"exclude":[{"regex":"tmp$"}]}`, "user::tmp", false},
+		{"prefix is case sensitive", `{"include":[{"prefix":"user::"}]}`, "USER::1", false},
+	}
+
+	for _, test := range tests {
+		var setting interface{}
+		if err := json.Unmarshal([]byte(test.setting), &setting); err != nil {
+			t.Fatalf("%s: failed to unmarshal setting, err: %v", test.name, err)
+		}
+
+		filters, err := ParseKeyFilters(setting)
+		if err != nil {
+			t.Fatalf("%s: got err %v", test.name, err)
+		}
+
+		if got := filters.Allows([]byte(test.key)); got != test.want {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+
+	var filters *KeyFilters
+	if !filters.Allows([]byte("any")) {
+		t.Errorf("nil filters: got false want true")
+	}
+}
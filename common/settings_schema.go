@@ -0,0 +1,194 @@
+package common
+
+import (
+	"fmt"
+)
+
+type SettingType string
+
+const (
+	SettingTypeBoolean    = SettingType("boolean")
+	SettingTypeDirPath    = SettingType("dir_path")
+	SettingTypeInteger    = SettingType("integer")
+	SettingTypeKeyFilters = SettingType("key_filters")
+	SettingTypeN1QLExpr   = SettingType("n1ql_expression")
+	SettingTypeString     = SettingType("string")
+	SettingTypeStringList = SettingType("string_list")
+)
+
+type SettingCategory string
+
+const (
+	SettingCategoryHandler   = SettingCategory("handler")
+	SettingCategoryProcess   = SettingCategory("process")
+	SettingCategoryRebalance = SettingCategory("rebalance")
+	SettingCategoryAppLog    = SettingCategory("app_log")
+	SettingCategoryPlasma    = SettingCategory("plasma")
+	SettingCategoryDcp       = SettingCategory("dcp")
+)
+
+// SettingSchema describes a function setting. Integer settings are kept as float64, as
+// they are in settings unmarshalled from JSON.
+type SettingSchema struct {
+	Name           string          `json:"name"`
+	Type           SettingType     `json:"type"`
+	Category       SettingCategory `json:"category"`
+	Default        interface{}     `json:"default,omitempty"` // nil when setting isn't defaulted
+	Minimum        *float64        `json:"minimum,omitempty"`
+	LessThan       string          `json:"less_than,omitempty"` // name of setting that must be greater
+	PossibleValues []string        `json:"possible_values,omitempty"`
+	HotReloadable  bool            `json:"hot_reloadable"` // applied to a deployed function without redeploy
+}
+
+func booleanSetting(name string, category SettingCategory, defaultValue interface{}, hotReloadable bool) SettingSchema {
+	return SettingSchema{Name: name, Type: SettingTypeBoolean, Category: category, Default: defaultValue, HotReloadable: hotReloadable}
+}
+
+func integerSetting(name string, category SettingCategory, defaultValue, minimum float64, hotReloadable bool) SettingSchema {
+	return SettingSchema{Name: name, Type: SettingTypeInteger, Category: category, Default: defaultValue, Minimum: &minimum, HotReloadable: hotReloadable}
+}
+
+func (s SettingSchema) lessThan(name string) SettingSchema {
+	s.LessThan = name
+	return s
+}
+
+// SettingsSchema is the registry of function settings, consumed both by settings
+// validation in service manager and by producer when loading settings
+var SettingsSchema = []SettingSchema{
+	// Handler related configurations
+	booleanSetting("processing_status", SettingCategoryHandler, nil, true),
+	booleanSetting("deployment_status", SettingCategoryHandler, nil, true),
+	booleanSetting("processing_paused", SettingCategoryHandler, false, true),
+	booleanSetting("binary_documents", SettingCategoryHandler, false, false),
+	integerSetting("checkpoint_interval", SettingCategoryHandler, 60000, 1, false),
+	booleanSetting("cleanup_timers", SettingCategoryHandler, false, false),
+	integerSetting("cpp_worker_thread_count", SettingCategoryHandler, 2, 1, false),
+	integerSetting("cron_timers_per_doc", SettingCategoryHandler, 1000, 1, false),
+	integerSetting("curl_timeout", SettingCategoryHandler, 500, 1, false),
+	{
+		Name:           "dcp_stream_boundary",
+		Type:           SettingTypeString,
+		Category:       SettingCategoryHandler,
+		Default:        string(DcpEverything),
+		PossibleValues: []string{string(DcpEverything), string(DcpFromNow)},
+	},
+	// Producer used to default deadline_timeout, execution_timeout and tick_duration to 2, 1 and
+	// 300000 for settings stored without them, these defaults apply to such functions on deploy now
+	integerSetting("deadline_timeout", SettingCategoryHandler, 4, 1, false),
+	booleanSetting("enable_recursive_mutation", SettingCategoryHandler, false, false),
+	integerSetting("execution_timeout", SettingCategoryHandler, 2, 1, false).lessThan("deadline_timeout"),
+	integerSetting("feedback_batch_size", SettingCategoryHandler, 100, 1, false),
+	integerSetting("feedback_read_buffer_size", SettingCategoryHandler, 65536, 1, false),
+	integerSetting("fuzz_offset", SettingCategoryHandler, 0, 0, false),
+	{Name: "include_xattrs", Type: SettingTypeStringList, Category: SettingCategoryHandler},
+	{Name: "key_filters", Type: SettingTypeKeyFilters, Category: SettingCategoryHandler},
+	integerSetting("lcb_inst_capacity", SettingCategoryHandler, 5, 1, false),
+	{
+		Name:           "log_level",
+		Type:           SettingTypeString,
+		Category:       SettingCategoryHandler,
+		Default:        "INFO",
+		PossibleValues: []string{"INFO", "ERROR", "WARNING", "DEBUG", "TRACE"},
+		HotReloadable:  true,
+	},
+	integerSetting("max_events_per_sec", SettingCategoryHandler, 0, 0, true),
+	integerSetting("max_timer_events_per_sec", SettingCategoryHandler, 0, 0, true),
+	integerSetting("skip_timer_threshold", SettingCategoryHandler, 86400, 1, true),
+	integerSetting("sock_batch_size", SettingCategoryHandler, 100, 1, false),
+	{Name: "source_filter", Type: SettingTypeN1QLExpr, Category: SettingCategoryHandler},
+	integerSetting("tick_duration", SettingCategoryHandler, 60000, 1, false),
+	integerSetting("timer_processing_tick_interval", SettingCategoryHandler, 500, 1, false),
+	integerSetting("worker_count", SettingCategoryHandler, 3, 1, false),
+	integerSetting("worker_feedback_queue_cap", SettingCategoryHandler, 10*1000, 1, false),
+	integerSetting("worker_queue_cap", SettingCategoryHandler, 100*1000, 1, false),
+	integerSetting("xattr_doc_timer_entry_prune_threshold", SettingCategoryHandler, 100, 1, false),
+
+	// Process related configuration
+	booleanSetting("breakpad_on", SettingCategoryProcess, false, false),
+
+	// Rebalance related configurations
+	integerSetting("vb_ownership_giveup_routine_count", SettingCategoryRebalance, 3, 1, true),
+	integerSetting("vb_ownership_takeover_routine_count", SettingCategoryRebalance, 3, 1, true),
+
+	// Application logging related configurations, app_log_dir defaults to eventing dir of the node
+	{Name: "app_log_dir", Type: SettingTypeDirPath, Category: SettingCategoryAppLog},
+	integerSetting("app_log_max_size", SettingCategoryAppLog, 1024*1024*10, 1, false),
+	integerSetting("app_log_max_files", SettingCategoryAppLog, 10, 1, false),
+
+	// Doc timer configurations for plasma
+	booleanSetting("auto_swapper", SettingCategoryPlasma, true, false),
+	booleanSetting("enable_snapshot_smr", SettingCategoryPlasma, false, false),
+	integerSetting("iterator_refresh_counter", SettingCategoryPlasma, 10*1000, 1, false),
+	integerSetting("lss_cleaner_max_threshold", SettingCategoryPlasma, 70, 1, false),
+	integerSetting("lss_cleaner_threshold", SettingCategoryPlasma, 30, 1, false),
+	integerSetting("lss_read_ahead_size", SettingCategoryPlasma, 1024*1024, 1, false),
+	integerSetting("max_delta_chain_len", SettingCategoryPlasma, 200, 1, false),
+	integerSetting("max_page_items", SettingCategoryPlasma, 400, 1, false),
+	integerSetting("min_page_items", SettingCategoryPlasma, 50, 1, false),
+	integerSetting("persist_interval", SettingCategoryPlasma, 5000, 1, false),
+	booleanSetting("use_memory_manager", SettingCategoryPlasma, true, false),
+
+	// DCP connection related configurations
+	integerSetting("data_chan_size", SettingCategoryDcp, 10000, 1, false),
+	integerSetting("dcp_gen_chan_size", SettingCategoryDcp, 10000, 1, false),
+	integerSetting("dcp_num_connections", SettingCategoryDcp, 1, 1, false),
+}
+
+// FillMissingSettings sets every defaulted setting missing in settings to its default
+func FillMissingSettings(settings map[string]interface{}) {
+	for _, schema := range SettingsSchema {
+		if schema.Default == nil {
+			continue
+		}
+
+		if _, ok := settings[schema.Name]; !ok {
+			settings[schema.Name] = schema.Default
+		}
+	}
+}
+
+// CheckSettingTypes returns an error if a setting doesn't have the type of its schema, as
+// settings unmarshalled from JSON carry, so that they can be type asserted once it passes
+func CheckSettingTypes(settings map[string]interface{}) error {
+	for _, schema := range SettingsSchema {
+		if value, ok := settings[schema.Name]; ok && !schema.Type.matches(value) {
+			return fmt.Errorf("setting %s has value %#v, which isn't of type %s", schema.Name, value, schema.Type)
+		}
+	}
+	return nil
+}
+
+func (t SettingType) matches(value interface{}) bool {
+	switch t {
+	case SettingTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+
+	case SettingTypeInteger:
+		_, ok := value.(float64)
+		return ok
+
+	case SettingTypeDirPath, SettingTypeN1QLExpr, SettingTypeString:
+		_, ok := value.(string)
+		return ok
+
+	case SettingTypeStringList:
+		list, ok := value.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+
+	case SettingTypeKeyFilters:
+		// Structure is checked as they are parsed
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,157 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFillMissingSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		want     map[string]interface{} // Checked along with every other defaulted setting being filled
+		missing  []string
+	}{
+		{
+			name:     "empty",
+			settings: map[string]interface{}{},
+			want: map[string]interface{}{
+				"processing_paused": false,
+				"worker_count":      float64(3),
+				"log_level":         "INFO",
+				// Producer defaulted these to 2, 1 and 300000 before
+				"deadline_timeout":  float64(4),
+				"execution_timeout": float64(2),
+				"tick_duration":     float64(60000),
+			},
+			missing: []string{"deployment_status", "processing_status", "key_filters", "source_filter", "include_xattrs", "app_log_dir"},
+		},
+		{
+			name: "supplied settings kept",
+			settings: map[string]interface{}{
+				"deployment_status": true,
+				"worker_count":      float64(8),
+				"log_level":         "TRACE",
+				"unknown":           "kept",
+			},
+			want: map[string]interface{}{
+				"deployment_status":   true,
+				"worker_count":        float64(8),
+				"log_level":           "TRACE",
+				"unknown":             "kept",
+				"checkpoint_interval": float64(60000),
+			},
+			missing: []string{"processing_status"},
+		},
+	}
+
+	for _, test := range tests {
+		FillMissingSettings(test.settings)
+
+		for setting, want := range test.want {
+			if got := test.settings[setting]; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s got %#v want %#v", test.name, setting, got, want)
+			}
+		}
+
+		for _, schema := range SettingsSchema {
+			if _, ok := test.settings[schema.Name]; schema.Default != nil && !ok {
+				t.Errorf("%s: %s not filled", test.name, schema.Name)
+			}
+		}
+
+		for _, setting := range test.missing {
+			if value, ok := test.settings[setting]; ok {
+				t.Errorf("%s: %s filled with %#v, though it has no default", test.name, setting, value)
+			}
+		}
+	}
+}
+
+func TestSettingsSchema(t *testing.T) {
+	schemas := make(map[string]SettingSchema)
+	for _, schema := range SettingsSchema {
+		if _, ok := schemas[schema.Name]; ok {
+			t.Errorf("%s: defined more than once", schema.Name)
+		}
+		schemas[schema.Name] = schema
+	}
+
+	for _, schema := range SettingsSchema {
+		if schema.Default != nil {
+			var ok bool
+			switch schema.Type {
+			case SettingTypeBoolean:
+				_, ok = schema.Default.(bool)
+			case SettingTypeInteger:
+				_, ok = schema.Default.(float64)
+			case SettingTypeString:
+				_, ok = schema.Default.(string)
+			}
+
+			if !ok {
+				t.Errorf("%s: default %#v doesn't match type %s", schema.Name, schema.Default, schema.Type)
+			}
+		}
+
+		if schema.Minimum != nil {
+			if value, ok := schema.Default.(float64); ok && value < *schema.Minimum {
+				t.Errorf("%s: default %v below minimum %v", schema.Name, value, *schema.Minimum)
+			}
+		}
+
+		if len(schema.PossibleValues) > 0 && schema.Default != nil {
+			found := false
+			for _, value := range schema.PossibleValues {
+				found = found || value == schema.Default
+			}
+
+			if !found {
+				t.Errorf("%s: default %v not among possible values %v", schema.Name, schema.Default, schema.PossibleValues)
+			}
+		}
+
+		if schema.LessThan != "" {
+			greater, ok := schemas[schema.LessThan]
+			if !ok || greater.Type != SettingTypeInteger {
+				t.Errorf("%s: must be less than %s, which isn't an integer setting", schema.Name, schema.LessThan)
+			} else if schema.Default != nil && greater.Default != nil && schema.Default.(float64) >= greater.Default.(float64) {
+				t.Errorf("%s: default %v isn't less than default of %s", schema.Name, schema.Default, schema.LessThan)
+			}
+		}
+	}
+}
+
+func TestCheckSettingTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		wantErr  bool
+	}{
+		{"empty", map[string]interface{}{}, false},
+		{"valid", map[string]interface{}{
+			"binary_documents": true,
+			"worker_count":     float64(3),
+			"log_level":        "INFO",
+			"app_log_dir":      "/tmp",
+			"source_filter":    "type = 'order'",
+			"include_xattrs":   []interface{}{"_sync"},
+			"key_filters":      map[string]interface{}{},
+			"unknown":          float64(1),
+		}, false},
+		{"boolean as string", map[string]interface{}{"binary_documents": "true"}, true},
+		{"integer as string", map[string]interface{}{"worker_count": "3"}, true},
+		{"string as integer", map[string]interface{}{"log_level": float64(5)}, true},
+		{"dir path as boolean", map[string]interface{}{"app_log_dir": false}, true},
+		{"list as string", map[string]interface{}{"include_xattrs": "_sync"}, true},
+		{"list of integers", map[string]interface{}{"include_xattrs": []interface{}{float64(1)}}, true},
+		{"null", map[string]interface{}{"source_filter": nil}, true},
+	}
+
+	for _, test := range tests {
+		err := CheckSettingTypes(test.settings)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: got err %v want err %v", test.name, err, test.wantErr)
+		}
+	}
+}
@@ -417,8 +417,25 @@ var startDCPFeedOpCallback = func(args ...interface{}) error {
 	feedName := args[1].(couchbase.DcpFeedName)
 	kvHostPort := args[2].(string)
 
+	// Delete times let KV send expirations as such, rather than as deletions. Till every node
+	// is upgraded, expirations keep coming as deletions, as KV nodes that can't send them
+	// otherwise would.
+	hostAddress := net.JoinHostPort(util.Localhost(), c.producer.GetNsServerPort())
+	expirySupported, err := util.ClusterCompatAtLeast(c.producer.Auth(), hostAddress,
+		expiryOpcodeMinMajor, expiryOpcodeMinMinor)
+	if err != nil {
+		logging.Errorf("%s [%s:%s:%d] Failed to get cluster compatibility, err: %v",
+			logPrefix, c.workerName, c.tcpPort, c.Pid(), err)
+		return err
+	}
+
+	flags := includeXATTRs
+	if expirySupported {
+		flags |= includeDeleteTimes
+	}
+
 	dcpFeed, err := c.cbBucket.StartDcpFeedOver(
-		feedName, uint32(0), includeXATTRs, []string{kvHostPort}, 0xABCD, c.dcpConfig)
+		feedName, uint32(0), flags, []string{kvHostPort}, 0xABCD, c.dcpConfig)
 
 	if err != nil {
 		logging.Errorf("%s [%s:%s:%d] Failed to start dcp feed for bucket: %v from kv node: %rs, err: %v",
@@ -14,10 +14,6 @@ func (c *Consumer) doLastSeqNoCheckpoint() {
 
 	c.checkpointTicker = time.NewTicker(c.checkpointInterval)
 
-	var vbBlob vbucketKVBlob
-	var cas gocb.Cas
-	var isNoEnt bool
-
 	for {
 		select {
 		case <-c.checkpointTicker.C:
@@ -28,48 +24,58 @@ func (c *Consumer) doLastSeqNoCheckpoint() {
 				return
 			}
 
-			util.Retry(util.NewFixedBackoff(clusterOpRetryInterval), getEventingNodeAddrOpCallback, c)
+			c.checkpointOwnedVbs()
 
-			for vbno := range c.vbProcessingStats {
+		case <-c.stopCheckpointingCh:
+			logging.Infof("%s [%s:%s:%d] Exited checkpointing routine",
+				logPrefix, c.workerName, c.tcpPort, c.Pid())
+			return
+		}
+	}
+}
 
-				// only checkpoint stats for vbuckets that the consumer instance owns
-				if c.ConsumerName() == c.vbProcessingStats.getVbStat(vbno, "assigned_worker") &&
-					c.NodeUUID() == c.vbProcessingStats.getVbStat(vbno, "node_uuid") {
+func (c *Consumer) checkpointOwnedVbs() {
+	logPrefix := "Consumer::checkpointOwnedVbs"
 
-					vbKey := fmt.Sprintf("%s::vb::%d", c.app.AppName, vbno)
+	var vbBlob vbucketKVBlob
+	var cas gocb.Cas
+	var isNoEnt bool
 
-					// Metadata blob doesn't exist probably the app is deployed for the first time.
-					util.Retry(util.NewFixedBackoff(bucketOpRetryInterval), getOpCallback, c, vbKey, &vbBlob, &cas, true, &isNoEnt)
-					if isNoEnt {
+	util.Retry(util.NewFixedBackoff(clusterOpRetryInterval), getEventingNodeAddrOpCallback, c)
 
-						logging.Infof("%s [%s:%s:%d] vb: %d Creating the initial metadata blob entry",
-							logPrefix, c.workerName, c.tcpPort, c.Pid(), vbno)
+	for vbno := range c.vbProcessingStats {
 
-						c.updateCheckpointInfo(vbKey, vbno, &vbBlob)
-						continue
-					}
+		// only checkpoint stats for vbuckets that the consumer instance owns
+		if c.ConsumerName() == c.vbProcessingStats.getVbStat(vbno, "assigned_worker") &&
+			c.NodeUUID() == c.vbProcessingStats.getVbStat(vbno, "node_uuid") {
 
-					// Steady state cluster
-					if c.NodeUUID() == vbBlob.NodeUUID && vbBlob.DCPStreamStatus == dcpStreamRunning {
-						c.updateCheckpointInfo(vbKey, vbno, &vbBlob)
-						continue
-					}
+			vbKey := fmt.Sprintf("%s::vb::%d", c.app.AppName, vbno)
 
-					// Needed to handle race between previous owner(another eventing node) and new owner(current node).
-					if vbBlob.CurrentVBOwner == "" && c.checkIfCurrentNodeShouldOwnVb(vbno) &&
-						c.checkIfCurrentConsumerShouldOwnVb(vbno) && vbBlob.DCPStreamStatus == dcpStreamStopped {
+			// Metadata blob doesn't exist probably the app is deployed for the first time.
+			util.Retry(util.NewFixedBackoff(bucketOpRetryInterval), getOpCallback, c, vbKey, &vbBlob, &cas, true, &isNoEnt)
+			if isNoEnt {
 
-						c.updateCheckpointInfo(vbKey, vbno, &vbBlob)
-						continue
-					}
+				logging.Infof("%s [%s:%s:%d] vb: %d Creating the initial metadata blob entry",
+					logPrefix, c.workerName, c.tcpPort, c.Pid(), vbno)
 
-				}
+				c.updateCheckpointInfo(vbKey, vbno, &vbBlob)
+				continue
+			}
+
+			// Steady state cluster
+			if c.NodeUUID() == vbBlob.NodeUUID && vbBlob.DCPStreamStatus == dcpStreamRunning {
+				c.updateCheckpointInfo(vbKey, vbno, &vbBlob)
+				continue
+			}
+
+			// Needed to handle race between previous owner(another eventing node) and new owner(current node).
+			if vbBlob.CurrentVBOwner == "" && c.checkIfCurrentNodeShouldOwnVb(vbno) &&
+				c.checkIfCurrentConsumerShouldOwnVb(vbno) && vbBlob.DCPStreamStatus == dcpStreamStopped {
+
+				c.updateCheckpointInfo(vbKey, vbno, &vbBlob)
+				continue
 			}
 
-		case <-c.stopCheckpointingCh:
-			logging.Infof("%s [%s:%s:%d] Exited checkpointing routine",
-				logPrefix, c.workerName, c.tcpPort, c.Pid())
-			return
 		}
 	}
 }
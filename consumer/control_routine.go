@@ -73,6 +73,14 @@ func (c *Consumer) controlRoutine() {
 				c.vbOwnershipTakeoverRoutineCount = int(val.(float64))
 			}
 
+			if val, ok := settings["processing_paused"]; ok {
+				if val.(bool) {
+					c.pauseProcessing()
+				} else {
+					c.resumeProcessing()
+				}
+			}
+
 		case <-c.restartVbDcpStreamTicker.C:
 
 		retryVbsRemainingToRestream:
@@ -85,6 +93,11 @@ func (c *Consumer) controlRoutine() {
 				continue
 			}
 
+			// Owned vbuckets are restreamed from their checkpoint on resume
+			if c.processingPaused() {
+				continue
+			}
+
 			// Verify if the app is deployed or not before trying to reopen vbucket DCP streams
 			// for the ones which recently have returned STREAMEND. QE frequently does flush
 			// on source bucket right after undeploy
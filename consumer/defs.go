@@ -3,6 +3,7 @@ package consumer
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"hash/crc32"
 	"net"
 	"os/exec"
@@ -15,6 +16,7 @@ import (
 	mcd "github.com/couchbase/eventing/dcp/transport"
 	cb "github.com/couchbase/eventing/dcp/transport/client"
 	"github.com/couchbase/eventing/suptree"
+	"github.com/couchbase/eventing/util"
 	"github.com/couchbase/gocb"
 	"github.com/couchbase/plasma"
 	"github.com/google/flatbuffers/go"
@@ -31,9 +33,23 @@ const (
 )
 
 const (
-	dcpDatatypeJSON      = uint8(1)
-	dcpDatatypeJSONXattr = uint8(5)
-	includeXATTRs        = uint32(4)
+	dcpDatatypeBinary      = uint8(0)
+	dcpDatatypeJSON        = uint8(1)
+	dcpDatatypeSnappy      = uint8(2)
+	dcpDatatypeBinaryXattr = uint8(4)
+	dcpDatatypeJSONXattr   = uint8(5)
+	includeXATTRs          = uint32(4)
+	includeDeleteTimes     = uint32(0x20)
+
+	// Cluster compatibility from which KV sends expirations with their own opcode
+	expiryOpcodeMinMajor = 6
+	expiryOpcodeMinMinor = 6
+)
+
+// Datatype of documents in DCP event metadata sent to the worker, omitted for JSON
+const (
+	dcpDocBinary = "binary"
+	dcpDocJSON   = "json"
 )
 
 // plasma related constants
@@ -87,6 +103,12 @@ const (
 	socketWriteTimerInterval = time.Duration(5000) * time.Millisecond
 
 	updateCPPStatsTickInterval = time.Duration(5000) * time.Millisecond
+
+	// Interval for checking whether events in flight have been processed on pause
+	pauseDrainCheckInterval = time.Duration(1000) * time.Millisecond
+
+	// Pause checkpoints without waiting any longer for events in flight to be processed
+	pauseDrainTimeout = time.Duration(60000) * time.Millisecond
 )
 
 const (
@@ -112,12 +134,22 @@ type vbFlogEntry struct {
 }
 
 type dcpMetadata struct {
-	Cas     uint64 `json:"cas"`
-	DocID   string `json:"id"`
-	Expiry  uint32 `json:"expiration"`
-	Flag    uint32 `json:"flags"`
-	Vbucket uint16 `json:"vb"`
-	SeqNo   uint64 `json:"seq"`
+	Cas      uint64 `json:"cas"`
+	DocID    string `json:"id"`
+	Expiry   uint32 `json:"expiration"`
+	Flag     uint32 `json:"flags"`
+	Vbucket  uint16 `json:"vb"`
+	SeqNo    uint64 `json:"seq"`
+	Datatype string `json:"datatype,omitempty"`
+
+	*dcpXattrsMetadata // Sent only when include_xattrs setting is present
+}
+
+// Sent as is, zero values included, as handlers can't tell them from missing fields otherwise
+type dcpXattrsMetadata struct {
+	RevSeqno uint64                     `json:"rev_seqno"`
+	LockTime uint32                     `json:"lock_time"`
+	Xattrs   map[string]json.RawMessage `json:"xattrs"`
 }
 
 // Consumer is responsible interacting with c++ v8 worker over local tcp port
@@ -166,7 +198,9 @@ type Consumer struct {
 	executionTimeout       int
 	gocbBucket             *gocb.Bucket
 	gocbMetaBucket         *gocb.Bucket
+	isProcessingPaused     bool // Access controlled by default lock
 	isRebalanceOngoing     bool
+	isStartDcpPending      bool                          // Set when consumer bootstraps with processing paused
 	ipcType                string                        // ipc mechanism used to communicate with cpp workers - af_inet/af_unix
 	kvHostDcpFeedMap       map[string]*couchbase.DcpFeed // Access controlled by hostDcpFeedRWMutex
 	executionStats         map[string]interface{}        // Access controlled by statsRWMutex
@@ -218,6 +252,13 @@ type Consumer struct {
 	timerAddrs             map[string]map[string]string
 	vbPlasmaStore          *plasma.Plasma
 
+	keyFilters   *common.KeyFilters // nil when every key is handed to the handler
+	sourceFilter *util.SourceFilter // nil when every document is handed to the handler
+
+	// Shared with other consumers of the producer, as limits apply to the function as a whole
+	dcpEventRateLimiter   *util.RateLimiter
+	timerEventRateLimiter *util.RateLimiter
+
 	plasmaStoreCh     chan *plasmaStoreEntry
 	plasmaStoreStopCh chan struct{}
 
@@ -243,6 +284,8 @@ type Consumer struct {
 	timerCleanupStopCh          chan struct{}
 	timerProcessingTickInterval time.Duration
 
+	binaryDocuments         bool     // Hand mutations of non-JSON documents to the handler
+	includeXattrs           []string // User xattrs passed on in meta, nil when meta carries only the basic fields
 	enableRecursiveMutation bool
 
 	dcpStreamBoundary common.DcpStreamBoundary
@@ -345,11 +388,23 @@ type Consumer struct {
 	aggMessagesSentCounter         uint64
 	crontimerMessagesProcessed     uint64
 	dcpDeletionCounter             uint64
+	dcpBinaryMutationCounter       uint64
+	dcpDeletionFilteredCounter     uint64
+	dcpExpirationCounter           uint64
+	dcpExpirationFilteredCounter   uint64
 	dcpMutationCounter             uint64
+	dcpMutationFilteredCounter     uint64
+	dcpSourceFilteredCounter       uint64
+	dcpSnappyCompressedBytes       uint64 // Accessed atomically
+	dcpSnappyUncompressedBytes     uint64 // Accessed atomically
 	doctimerMessagesProcessed      uint64
 	doctimerResponsesRecieved      uint64
 	errorParsingDocTimerResponses  uint64
 
+	// Time feeds were held off by rate limiters
+	dcpEventsThrottled   time.Duration
+	timerEventsThrottled time.Duration
+
 	timerMessagesProcessedPSec int
 
 	// capture dcp operation stats, granularity of these stats depend on statsTickInterval
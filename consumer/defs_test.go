@@ -0,0 +1,56 @@
+package consumer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDcpMetadataJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		meta dcpMetadata
+		want string
+	}{
+		{
+			name: "without include_xattrs",
+			meta: dcpMetadata{Cas: 1, DocID: "doc", Vbucket: 2, SeqNo: 3},
+			want: `{"cas":1,"id":"doc","expiration":0,"flags":0,"vb":2,"seq":3}`,
+		},
+		{
+			name: "with include_xattrs zero values",
+			meta: dcpMetadata{
+				DocID:             "doc",
+				Datatype:          dcpDocJSON,
+				dcpXattrsMetadata: &dcpXattrsMetadata{Xattrs: map[string]json.RawMessage{}},
+			},
+			want: `{"cas":0,"id":"doc","expiration":0,"flags":0,"vb":0,"seq":0,"datatype":"json",` +
+				`"rev_seqno":0,"lock_time":0,"xattrs":{}}`,
+		},
+		{
+			name: "with include_xattrs",
+			meta: dcpMetadata{
+				DocID:    "doc",
+				Datatype: dcpDocBinary,
+				dcpXattrsMetadata: &dcpXattrsMetadata{
+					RevSeqno: 4,
+					LockTime: 5,
+					Xattrs:   map[string]json.RawMessage{"app": json.RawMessage(`{"a":1}`)},
+				},
+			},
+			want: `{"cas":0,"id":"doc","expiration":0,"flags":0,"vb":0,"seq":0,"datatype":"binary",` +
+				`"rev_seqno":4,"lock_time":5,"xattrs":{"app":{"a":1}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := json.Marshal(&test.meta)
+		if err != nil {
+			t.Errorf("%s: got err %v", test.name, err)
+			continue
+		}
+
+		if string(got) != test.want {
+			t.Errorf("%s: got %s want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -89,10 +89,49 @@ func (c *Consumer) GetEventProcessingStats() map[string]uint64 {
 		stats["DCP_DELETION_SENT_TO_WORKER"] = c.dcpDeletionCounter
 	}
 
+	if c.dcpExpirationCounter > 0 {
+		stats["DCP_EXPIRATION_SENT_TO_WORKER"] = c.dcpExpirationCounter
+	}
+
+	if c.dcpBinaryMutationCounter > 0 {
+		stats["DCP_BINARY_MUTATION_SENT_TO_WORKER"] = c.dcpBinaryMutationCounter
+	}
+
+	if c.dcpMutationFilteredCounter > 0 {
+		stats["DCP_MUTATION_FILTERED"] = c.dcpMutationFilteredCounter
+	}
+
+	if c.dcpSourceFilteredCounter > 0 {
+		stats["DCP_MUTATION_SOURCE_FILTERED"] = c.dcpSourceFilteredCounter
+	}
+
+	if compressed := atomic.LoadUint64(&c.dcpSnappyCompressedBytes); compressed > 0 {
+		uncompressed := atomic.LoadUint64(&c.dcpSnappyUncompressedBytes)
+		stats["DCP_SNAPPY_COMPRESSED_BYTES"] = compressed
+		stats["DCP_SNAPPY_UNCOMPRESSED_BYTES"] = uncompressed
+		stats["DCP_SNAPPY_COMPRESSION_RATIO_PCT"] = uncompressed * 100 / compressed
+	}
+
+	if c.dcpDeletionFilteredCounter > 0 {
+		stats["DCP_DELETION_FILTERED"] = c.dcpDeletionFilteredCounter
+	}
+
+	if c.dcpExpirationFilteredCounter > 0 {
+		stats["DCP_EXPIRATION_FILTERED"] = c.dcpExpirationFilteredCounter
+	}
+
 	if c.aggMessagesSentCounter > 0 {
 		stats["AGG_MESSAGES_SENT_TO_WORKER"] = c.aggMessagesSentCounter
 	}
 
+	if c.dcpEventsThrottled > 0 {
+		stats["DCP_EVENTS_THROTTLED_MS"] = uint64(c.dcpEventsThrottled / time.Millisecond)
+	}
+
+	if c.timerEventsThrottled > 0 {
+		stats["TIMER_EVENTS_THROTTLED_MS"] = uint64(c.timerEventsThrottled / time.Millisecond)
+	}
+
 	if c.doctimerResponsesRecieved > 0 {
 		stats["DOC_TIMER_RESPONSES_RECEIVED"] = c.doctimerResponsesRecieved
 	}
@@ -298,7 +337,7 @@ func (c *Consumer) GetLatencyStats() map[string]uint64 {
 	return latencyStats
 }
 
-// GetExecutionStats returns OnUpdate/OnDelete success/failure stats for event handlers from cpp world
+// GetExecutionStats returns OnUpdate/OnDelete/OnExpiry success/failure stats for event handlers from cpp world
 func (c *Consumer) GetExecutionStats() map[string]interface{} {
 	c.statsRWMutex.RLock()
 	defer c.statsRWMutex.RUnlock()
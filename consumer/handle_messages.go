@@ -1,6 +1,7 @@
 package consumer
 
 import (
+	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
 	"io"
@@ -384,6 +385,48 @@ func (c *Consumer) sendCronTimerEvent(e *timerMsg, sendToDebugger bool) {
 }
 
 func (c *Consumer) sendDcpEvent(e *memcached.DcpEvent, sendToDebugger bool) {
+	c.sendDcpEventWithXattrs(e, nil, sendToDebugger)
+}
+
+// Returns metadata of e passed on to the handler, along with the value it's handed as
+func (c *Consumer) makeDcpMetadata(e *memcached.DcpEvent, xattrs map[string]json.RawMessage) (m dcpMetadata, value []byte) {
+	m = dcpMetadata{
+		Cas:     e.Cas,
+		DocID:   string(e.Key),
+		Expiry:  e.Expiry,
+		Flag:    e.Flags,
+		Vbucket: e.VBucket,
+		SeqNo:   e.Seqno,
+	}
+
+	value = e.Value
+
+	// Worker hands documents to V8 as strings, which binary documents can't be sent as
+	if e.Opcode == mcd.DCP_MUTATION && e.Datatype&dcpDatatypeJSON == 0 {
+		m.Datatype = dcpDocBinary
+		value = []byte(base64.StdEncoding.EncodeToString(e.Value))
+	}
+
+	if c.includeXattrs != nil {
+		if xattrs == nil {
+			xattrs = make(map[string]json.RawMessage)
+		}
+		m.dcpXattrsMetadata = &dcpXattrsMetadata{
+			RevSeqno: e.RevSeqno,
+			LockTime: e.LockTime,
+			Xattrs:   xattrs,
+		}
+		if e.Opcode == mcd.DCP_MUTATION && m.Datatype == "" {
+			m.Datatype = dcpDocJSON
+		}
+	}
+
+	return
+}
+
+// xattrs are the user xattrs of the document named by include_xattrs setting, which
+// along with the rest of DCP metadata are only passed on when the setting is present
+func (c *Consumer) sendDcpEventWithXattrs(e *memcached.DcpEvent, xattrs map[string]json.RawMessage, sendToDebugger bool) {
 
 	if sendToDebugger {
 	checkDebuggerStarted:
@@ -393,14 +436,7 @@ func (c *Consumer) sendDcpEvent(e *memcached.DcpEvent, sendToDebugger bool) {
 		}
 	}
 
-	m := dcpMetadata{
-		Cas:     e.Cas,
-		DocID:   string(e.Key),
-		Expiry:  e.Expiry,
-		Flag:    e.Flags,
-		Vbucket: e.VBucket,
-		SeqNo:   e.Seqno,
-	}
+	m, value := c.makeDcpMetadata(e, xattrs)
 
 	metadata, err := json.Marshal(&m)
 	if err != nil {
@@ -421,7 +457,54 @@ func (c *Consumer) sendDcpEvent(e *memcached.DcpEvent, sendToDebugger bool) {
 		dcpHeader, hBuilder = c.makeDcpDeletionHeader(partition, string(metadata))
 	}
 
-	dcpPayload, pBuilder := c.makeDcpPayload(e.Key, e.Value)
+	if e.Opcode == mcd.DCP_EXPIRATION {
+		dcpHeader, hBuilder = c.makeDcpExpirationHeader(partition, string(metadata))
+	}
+
+	dcpPayload, pBuilder := c.makeDcpPayload(e.Key, value)
+
+	msg := &msgToTransmit{
+		msg: &message{
+			Header:  dcpHeader,
+			Payload: dcpPayload,
+		},
+		sendToDebugger: sendToDebugger,
+		prioritize:     false,
+		headerBuilder:  hBuilder,
+		payloadBuilder: pBuilder,
+	}
+
+	c.sendMessage(msg)
+}
+
+// Filtered events only advance the vb seq no checkpointed by the worker, hence carry neither
+// document metadata nor value
+func (c *Consumer) sendFilteredDcpEvent(e *memcached.DcpEvent, sendToDebugger bool) {
+
+	if sendToDebugger {
+	checkDebuggerStarted:
+		if !c.debuggerStarted {
+			time.Sleep(retryInterval)
+			goto checkDebuggerStarted
+		}
+	}
+
+	m := dcpMetadata{
+		Vbucket: e.VBucket,
+		SeqNo:   e.Seqno,
+	}
+
+	metadata, err := json.Marshal(&m)
+	if err != nil {
+		logging.Errorf("CRHM[%s:%s:%s:%d] key: %ru failed to marshal metadata",
+			c.app.AppName, c.workerName, c.tcpPort, c.Pid(), string(e.Key))
+		return
+	}
+
+	partition := int16(util.VbucketByKey(e.Key, cppWorkerPartitionCount))
+
+	dcpHeader, hBuilder := c.makeDcpFilteredHeader(partition, string(metadata))
+	dcpPayload, pBuilder := c.makeDcpPayload(nil, nil)
 
 	msg := &msgToTransmit{
 		msg: &message{
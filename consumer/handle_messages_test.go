@@ -0,0 +1,54 @@
+package consumer
+
+import (
+	"encoding/base64"
+	"testing"
+
+	mcd "github.com/couchbase/eventing/dcp/transport"
+	"github.com/couchbase/eventing/dcp/transport/client"
+)
+
+func TestMakeDcpMetadata(t *testing.T) {
+	binaryDoc := []byte{0x00, 0xff, 'b', 'i', 'n'}
+
+	tests := []struct {
+		name          string
+		includeXattrs []string
+		opcode        mcd.CommandCode
+		datatype      uint8
+		value         []byte
+		wantDatatype  string
+		wantValue     string
+		wantXattrs    bool
+	}{
+		{"json", nil, mcd.DCP_MUTATION, dcpDatatypeJSON, []byte(`{"a":1}`), "", `{"a":1}`, false},
+		{"json with include_xattrs", []string{}, mcd.DCP_MUTATION, dcpDatatypeJSON, []byte(`{"a":1}`), dcpDocJSON, `{"a":1}`, true},
+		{"binary", nil, mcd.DCP_MUTATION, dcpDatatypeBinary, binaryDoc, dcpDocBinary,
+			base64.StdEncoding.EncodeToString(binaryDoc), false},
+		{"binary with xattrs stripped", []string{"app"}, mcd.DCP_MUTATION, dcpDatatypeBinaryXattr, binaryDoc, dcpDocBinary,
+			base64.StdEncoding.EncodeToString(binaryDoc), true},
+		{"deletion", nil, mcd.DCP_DELETION, dcpDatatypeBinary, nil, "", "", false},
+	}
+
+	for _, test := range tests {
+		c := &Consumer{includeXattrs: test.includeXattrs}
+		e := &memcached.DcpEvent{Opcode: test.opcode, Key: []byte("doc"), Seqno: 7, Datatype: test.datatype, Value: test.value}
+
+		m, value := c.makeDcpMetadata(e, nil)
+		if m.Datatype != test.wantDatatype {
+			t.Errorf("%s: got datatype %q want %q", test.name, m.Datatype, test.wantDatatype)
+		}
+
+		if string(value) != test.wantValue {
+			t.Errorf("%s: got value %q want %q", test.name, value, test.wantValue)
+		}
+
+		if gotXattrs := m.dcpXattrsMetadata != nil; gotXattrs != test.wantXattrs {
+			t.Errorf("%s: got xattrs metadata %v want %v", test.name, gotXattrs, test.wantXattrs)
+		}
+
+		if m.DocID != "doc" || m.SeqNo != 7 {
+			t.Errorf("%s: got id %s seq %d want doc and 7", test.name, m.DocID, m.SeqNo)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package consumer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/couchbase/eventing/dcp"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+	"github.com/couchbase/gocb"
+)
+
+func (c *Consumer) processingPaused() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.isProcessingPaused
+}
+
+// Closes dcp streams for owned vbuckets and checkpoints their progress once events already
+// read off the streams are processed. Timer processing halts while paused. Vbucket ownership
+// and timer store are retained so that processing could resume from the checkpoint.
+func (c *Consumer) pauseProcessing() {
+	logPrefix := "Consumer::pauseProcessing"
+
+	c.Lock()
+	if c.isProcessingPaused {
+		c.Unlock()
+		return
+	}
+	c.isProcessingPaused = true
+	c.vbsRemainingToRestream = make([]uint16, 0)
+	c.Unlock()
+
+	vbsOwned := c.getCurrentlyOwnedVbs()
+
+	logging.Infof("%s [%s:%s:%d] Pausing processing, closing dcp streams for vbs len: %d dump: %v",
+		logPrefix, c.workerName, c.tcpPort, c.Pid(), len(vbsOwned), util.Condense(vbsOwned))
+
+	for _, vb := range vbsOwned {
+		c.RLock()
+		dcpFeed, ok := c.vbDcpFeedMap[vb]
+		c.RUnlock()
+
+		if !ok {
+			continue
+		}
+
+		err := dcpFeed.DcpCloseStream(vb, vb)
+		if err != nil {
+			logging.Errorf("%s [%s:%s:%d] vb: %v Failed to close dcp stream, err: %v",
+				logPrefix, c.workerName, c.tcpPort, c.Pid(), vb, err)
+		}
+	}
+
+	c.drainInflightEvents()
+	c.checkpointOwnedVbs()
+
+	logging.Infof("%s [%s:%s:%d] Paused processing", logPrefix, c.workerName, c.tcpPort, c.Pid())
+}
+
+// Waits for events read off dcp streams before they were closed to be processed by the worker,
+// so that checkpoint taken on pause covers them. Queue sizes reported by the worker may lag
+// behind, hence its queues have to be seen empty on consecutive checks.
+func (c *Consumer) drainInflightEvents() {
+	logPrefix := "Consumer::drainInflightEvents"
+
+	deadline := time.Now().Add(pauseDrainTimeout)
+	drainedChecks := 0
+
+	for drainedChecks < 2 {
+		if time.Now().After(deadline) {
+			logging.Warnf("%s [%s:%s:%d] Events still in flight after %v, checkpointing regardless, dcp feed len: %d",
+				logPrefix, c.workerName, c.tcpPort, c.Pid(), pauseDrainTimeout, len(c.aggDCPFeed))
+			return
+		}
+
+		if len(c.aggDCPFeed) == 0 {
+			// Prompts the worker to report its queue sizes
+			c.sendGetExecutionStats(false)
+		}
+
+		time.Sleep(pauseDrainCheckInterval)
+
+		if eventsDrained(len(c.aggDCPFeed), c.cppQueueSizes) {
+			drainedChecks++
+		} else {
+			drainedChecks = 0
+		}
+	}
+
+	logging.Infof("%s [%s:%s:%d] Events in flight processed", logPrefix, c.workerName, c.tcpPort, c.Pid())
+}
+
+func eventsDrained(aggDCPFeedLen int, queueSizes *cppQueueSize) bool {
+	return aggDCPFeedLen == 0 && queueSizes != nil &&
+		queueSizes.AggQueueSize == 0 && queueSizes.DocTimerQueueSize == 0
+}
+
+// Stream is restarted from the lower of the last seq no processed and the last one whose doc
+// timers were stored, so that neither mutations nor the timers they created are missed.
+func resumeSeqNo(vbBlob *vbucketKVBlob) uint64 {
+	if vbBlob.LastDocTimerFeedbackSeqNo < vbBlob.LastSeqNoProcessed {
+		return vbBlob.LastDocTimerFeedbackSeqNo
+	}
+	return vbBlob.LastSeqNoProcessed
+}
+
+// Restreams owned vbuckets from their last checkpointed seq no, dcp_stream_boundary
+// is only honoured for vbuckets that have never been checkpointed.
+func (c *Consumer) resumeProcessing() {
+	logPrefix := "Consumer::resumeProcessing"
+
+	c.Lock()
+	if !c.isProcessingPaused {
+		c.Unlock()
+		return
+	}
+	c.isProcessingPaused = false
+	isStartDcpPending := c.isStartDcpPending
+	c.isStartDcpPending = false
+	c.Unlock()
+
+	if isStartDcpPending {
+		logging.Infof("%s [%s:%s:%d] Resuming processing, starting deferred dcp streams",
+			logPrefix, c.workerName, c.tcpPort, c.Pid())
+
+		var flogs couchbase.FailoverLog
+		util.Retry(util.NewFixedBackoff(bucketOpRetryInterval), getFailoverLogOpCallback, c, &flogs)
+
+		c.startDcp(flogs)
+		return
+	}
+
+	vbsOwned := c.getCurrentlyOwnedVbs()
+
+	logging.Infof("%s [%s:%s:%d] Resuming processing, restreaming vbs len: %d dump: %v",
+		logPrefix, c.workerName, c.tcpPort, c.Pid(), len(vbsOwned), util.Condense(vbsOwned))
+
+	for _, vb := range vbsOwned {
+		vbKey := fmt.Sprintf("%s::vb::%d", c.app.AppName, vb)
+
+		var vbBlob vbucketKVBlob
+		var cas gocb.Cas
+
+		util.Retry(util.NewFixedBackoff(bucketOpRetryInterval), getOpCallback, c, vbKey, &vbBlob, &cas, false)
+
+		if vbBlob.NodeUUID != c.NodeUUID() {
+			logging.Infof("%s [%s:%s:%d] vb: %v metadata node uuid: %v, reclaiming it via restream routine",
+				logPrefix, c.workerName, c.tcpPort, c.Pid(), vb, vbBlob.NodeUUID)
+
+			c.Lock()
+			c.vbsRemainingToRestream = append(c.vbsRemainingToRestream, vb)
+			c.Unlock()
+			continue
+		}
+
+		streamStartSeqNo := resumeSeqNo(&vbBlob)
+
+		c.vbProcessingStats.updateVbStat(vb, "last_processed_seq_no", vbBlob.LastSeqNoProcessed)
+		c.vbProcessingStats.updateVbStat(vb, "last_doc_timer_feedback_seqno", vbBlob.LastDocTimerFeedbackSeqNo)
+
+		c.dcpRequestStreamHandle(vb, &vbBlob, streamStartSeqNo)
+	}
+}
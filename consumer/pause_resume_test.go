@@ -0,0 +1,52 @@
+package consumer
+
+import (
+	"testing"
+)
+
+func TestResumeSeqNo(t *testing.T) {
+	tests := []struct {
+		name              string
+		lastProcessed     uint64
+		lastTimerFeedback uint64
+		want              uint64
+	}{
+		{"timer feedback behind", 100, 80, 80},
+		{"processed behind", 80, 100, 80},
+		{"equal", 100, 100, 100},
+		{"never processed", 0, 0, 0},
+		{"no timer feedback yet", 100, 0, 0},
+	}
+
+	for _, test := range tests {
+		vbBlob := &vbucketKVBlob{
+			LastSeqNoProcessed:        test.lastProcessed,
+			LastDocTimerFeedbackSeqNo: test.lastTimerFeedback,
+		}
+
+		if got := resumeSeqNo(vbBlob); got != test.want {
+			t.Errorf("%s: got %d want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestEventsDrained(t *testing.T) {
+	tests := []struct {
+		name          string
+		aggDCPFeedLen int
+		queueSizes    *cppQueueSize
+		want          bool
+	}{
+		{"drained", 0, &cppQueueSize{}, true},
+		{"events in dcp feed", 3, &cppQueueSize{}, false},
+		{"events in worker queue", 0, &cppQueueSize{AggQueueSize: 1}, false},
+		{"doc timers in feedback queue", 0, &cppQueueSize{DocTimerQueueSize: 1}, false},
+		{"queue sizes not reported yet", 0, nil, false},
+	}
+
+	for _, test := range tests {
+		if got := eventsDrained(test.aggDCPFeedLen, test.queueSizes); got != test.want {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
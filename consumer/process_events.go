@@ -9,14 +9,17 @@ import (
 	"runtime/debug"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/couchbase/eventing/common"
 	"github.com/couchbase/eventing/dcp"
 	mcd "github.com/couchbase/eventing/dcp/transport"
+	cb "github.com/couchbase/eventing/dcp/transport/client"
 	"github.com/couchbase/eventing/logging"
 	"github.com/couchbase/eventing/util"
 	"github.com/couchbase/gocb"
+	"github.com/golang/snappy"
 )
 
 func (c *Consumer) processEvents() {
@@ -35,8 +38,10 @@ func (c *Consumer) processEvents() {
 			}
 		}
 
+		aggDCPFeed, docTimerEntryCh, cronTimerEntryCh, throttleCh := c.throttledFeeds()
+
 		select {
-		case e, ok := <-c.aggDCPFeed:
+		case e, ok := <-aggDCPFeed:
 			if ok == false {
 				logging.Infof("%s [%s:%s:%d] Closing DCP feed for bucket %q",
 					logPrefix, c.workerName, c.tcpPort, c.Pid(), c.bucket)
@@ -58,6 +63,29 @@ func (c *Consumer) processEvents() {
 				logging.Tracef("%s [%s:%s:%d] Got DCP_MUTATION for key: %ru datatype: %v",
 					logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), e.Datatype)
 
+				if c.filterDcpEvent(e) {
+					continue
+				}
+
+				if e.Datatype&dcpDatatypeSnappy != 0 {
+					compressedLen := len(e.Value)
+					if err := decompressDcpValue(e); err != nil {
+						logging.Errorf("%s [%s:%s:%d] key: %ru failed to decompress snappy value, err: %v",
+							logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), err)
+						c.skipDcpEvent(e)
+						continue
+					}
+					atomic.AddUint64(&c.dcpSnappyCompressedBytes, uint64(compressedLen))
+					atomic.AddUint64(&c.dcpSnappyUncompressedBytes, uint64(len(e.Value)))
+				}
+
+				// Dropped ahead of rate limiting, as they never reach the handler
+				if e.Datatype&dcpDatatypeJSON == 0 && !c.binaryDocuments {
+					continue
+				}
+
+				c.throttleDcpEvent()
+
 				if c.debuggerState == startDebug {
 
 					c.signalUpdateDebuggerInstBlobCh <- struct{}{}
@@ -76,7 +104,23 @@ func (c *Consumer) processEvents() {
 				}
 
 				switch e.Datatype {
+				case dcpDatatypeBinary, dcpDatatypeBinaryXattr:
+					xattrs, send := c.prepareBinaryMutation(e)
+					if !send {
+						continue
+					}
+
+					if !c.sendMsgToDebugger {
+						c.sendDcpEventWithXattrs(e, xattrs, c.sendMsgToDebugger)
+					} else {
+						go c.sendDcpEventWithXattrs(e, xattrs, c.sendMsgToDebugger)
+					}
+
 				case dcpDatatypeJSON:
+					if c.filterDcpMutationBySource(e) {
+						continue
+					}
+
 					if !c.sendMsgToDebugger {
 						c.dcpMutationCounter++
 						c.sendDcpEvent(e, c.sendMsgToDebugger)
@@ -85,130 +129,33 @@ func (c *Consumer) processEvents() {
 						go c.sendDcpEvent(e, c.sendMsgToDebugger)
 					}
 				case dcpDatatypeJSONXattr:
-					totalXattrLen := binary.BigEndian.Uint32(e.Value[0:])
-					totalXattrData := e.Value[4 : 4+totalXattrLen-1]
-
-					logging.Tracef("%s [%s:%s:%d] key: %ru totalXattrLen: %v totalXattrData: %ru",
-						logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), totalXattrLen, totalXattrData)
-
-					var xMeta xattrMetadata
-					var bytesDecoded uint32
-
-					// Try decoding all xattrs defined in io-vector encoding format
-					for bytesDecoded < totalXattrLen {
-						frameLength := binary.BigEndian.Uint32(totalXattrData)
-						bytesDecoded += 4
-						frameData := totalXattrData[4 : 4+frameLength-1]
-						bytesDecoded += frameLength
-						if bytesDecoded < totalXattrLen {
-							totalXattrData = totalXattrData[4+frameLength:]
-						}
-
-						if len(frameData) > len(xattrPrefix) {
-							if bytes.Compare(frameData[:len(xattrPrefix)], []byte(xattrPrefix)) == 0 {
-								toParse := frameData[len(xattrPrefix)+1:]
-
-								err := json.Unmarshal(toParse, &xMeta)
-								if err != nil {
-									continue
-								}
-							}
-						}
+					xMeta, xattrs := c.decodeXattrs(e)
+					if c.isRecursiveMutation(e, xMeta) {
+						continue
 					}
 
-					logging.Tracef("%s [%s:%s:%d] Key: %ru xmeta dump: %ru",
-						logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), fmt.Sprintf("%#v", xMeta))
-
-					// Validating for eventing xattr fields
-					if xMeta.Cas != "" {
-						cas, err := util.ConvertBigEndianToUint64([]byte(xMeta.Cas))
-						if err != nil {
-							logging.Errorf("%s [%s:%s:%d] Key: %ru failed to convert cas string from kv to uint64, err: %v",
-								logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), err)
-							continue
-						}
-
-						logging.Tracef("%s [%s:%s:%d] Key: %ru decoded cas: %v dcp cas: %v",
-							logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), cas, e.Cas)
-
-						// Send mutation to V8 CPP worker _only_ when DcpEvent.Cas != Cas field in xattr
-						if cas != e.Cas {
-							e.Value = e.Value[4+totalXattrLen:]
-
-							if crc32.Update(0, c.crcTable, e.Value) != xMeta.Digest {
-								if !c.sendMsgToDebugger {
-									logging.Tracef("%s [%s:%s:%d] Sending key: %ru to be processed by JS handlers as cas & crc have mismatched",
-										logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key))
-									c.dcpMutationCounter++
-									c.sendDcpEvent(e, c.sendMsgToDebugger)
-								} else {
-									c.dcpMutationCounter++
-									go c.sendDcpEvent(e, c.sendMsgToDebugger)
-								}
-							} else {
-
-								logging.Tracef("%s [%s:%s:%d] Sending key: %ru to be stored in plasma",
-									logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key))
-
-								// Enabling it until MB-28779 gets resolved
-								for _, timerEntry := range xMeta.Timers {
-
-									data := strings.Split(timerEntry, "::")
-
-									if len(data) == 3 {
-										pEntry := &plasmaStoreEntry{
-											callbackFn: data[2],
-											key:        string(e.Key),
-											timerTs:    data[1],
-											vb:         e.VBucket,
-										}
-
-										c.plasmaStoreCh <- pEntry
-										logging.Tracef("%s [%s:%s:%d] Sending key: %ru to be stored in plasma, timer entry: %v pEntry: %#v",
-											logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), timerEntry, pEntry)
-									}
-								}
-							}
-						} else {
-
-							// Enabling it until MB-28779 gets resolved
-							for _, timerEntry := range xMeta.Timers {
-
-								data := strings.Split(timerEntry, "::")
-
-								if len(data) == 3 {
-									pEntry := &plasmaStoreEntry{
-										callbackFn: data[2],
-										key:        string(e.Key),
-										timerTs:    data[1],
-										vb:         e.VBucket,
-									}
-
-									c.plasmaStoreCh <- pEntry
-									logging.Tracef("%s [%s:%s:%d] Sending key: %ru to be stored in plasma, timer entry: %v pEntry: %#v",
-										logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), timerEntry, pEntry)
-								}
-							}
-
-							logging.Tracef("%s [%s:%s:%d] Skipping recursive mutation for key: %ru vb: %v, xmeta: %ru",
-								logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), e.VBucket, fmt.Sprintf("%#v", xMeta))
+					if c.filterDcpMutationBySource(e) {
+						continue
+					}
 
-						}
+					if !c.sendMsgToDebugger {
+						logging.Tracef("%s [%s:%s:%d] Sending key: %ru to be processed by JS handlers",
+							logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key))
+						c.dcpMutationCounter++
+						c.sendDcpEventWithXattrs(e, xattrs, c.sendMsgToDebugger)
 					} else {
-						e.Value = e.Value[4+totalXattrLen:]
-						if !c.sendMsgToDebugger {
-							logging.Tracef("%s [%s:%s:%d] Sending key: %ru to be processed by JS handlers because no eventing xattrs",
-								logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key))
-							c.dcpMutationCounter++
-							c.sendDcpEvent(e, c.sendMsgToDebugger)
-						} else {
-							c.dcpMutationCounter++
-							go c.sendDcpEvent(e, c.sendMsgToDebugger)
-						}
+						c.dcpMutationCounter++
+						go c.sendDcpEventWithXattrs(e, xattrs, c.sendMsgToDebugger)
 					}
 				}
 
-			case mcd.DCP_DELETION:
+			case mcd.DCP_DELETION, mcd.DCP_EXPIRATION:
+				if c.filterDcpEvent(e) {
+					continue
+				}
+
+				c.throttleDcpEvent()
+
 				if c.debuggerState == startDebug {
 
 					c.signalUpdateDebuggerInstBlobCh <- struct{}{}
@@ -225,11 +172,16 @@ func (c *Consumer) processEvents() {
 					c.debuggerState = stopDebug
 				}
 
-				if !c.sendMsgToDebugger {
+				// Expirations go to OnExpiry, or to OnDelete for handlers without one
+				if e.Opcode == mcd.DCP_EXPIRATION {
+					c.dcpExpirationCounter++
+				} else {
 					c.dcpDeletionCounter++
+				}
+
+				if !c.sendMsgToDebugger {
 					c.sendDcpEvent(e, c.sendMsgToDebugger)
 				} else {
-					c.dcpDeletionCounter++
 					go c.sendDcpEvent(e, c.sendMsgToDebugger)
 				}
 
@@ -332,6 +284,13 @@ func (c *Consumer) processEvents() {
 				}
 				c.vbsStreamRRWMutex.Unlock()
 
+				// Streams closed on pause retain vbucket ownership, so metadata is left untouched
+				if c.processingPaused() {
+					logging.Infof("%s [%s:%s:%d] vb: %v processing is paused, retaining ownership",
+						logPrefix, c.workerName, c.tcpPort, c.Pid(), e.VBucket)
+					continue
+				}
+
 				// Store the latest state of vbucket processing stats in the metadata bucket
 				vbKey := fmt.Sprintf("%s::vb::%d", c.app.AppName, e.VBucket)
 
@@ -382,7 +341,7 @@ func (c *Consumer) processEvents() {
 			default:
 			}
 
-		case e, ok := <-c.docTimerEntryCh:
+		case e, ok := <-docTimerEntryCh:
 			if ok == false {
 				logging.Infof("%s [%s:%s:%d] Closing doc timer chan", logPrefix, c.workerName, c.tcpPort, c.Pid())
 
@@ -390,10 +349,12 @@ func (c *Consumer) processEvents() {
 				return
 			}
 
+			c.throttleTimerEvents(1)
+
 			c.doctimerMessagesProcessed++
 			c.sendDocTimerEvent(e, c.sendMsgToDebugger)
 
-		case e, ok := <-c.cronTimerEntryCh:
+		case e, ok := <-cronTimerEntryCh:
 			if ok == false {
 				logging.Infof("%s [%s:%s:%d] Closing non_doc timer chan", logPrefix, c.workerName, c.tcpPort, c.Pid())
 
@@ -401,9 +362,14 @@ func (c *Consumer) processEvents() {
 				return
 			}
 
+			c.throttleTimerEvents(e.msgCount)
+
 			c.crontimerMessagesProcessed += uint64(e.msgCount)
 			c.sendCronTimerEvent(e, c.sendMsgToDebugger)
 
+		case <-throttleCh:
+			// Rate limits are looked at again on next iteration
+
 		case <-c.statsTicker.C:
 
 			vbsOwned := c.getCurrentlyOwnedVbs()
@@ -463,6 +429,234 @@ func (c *Consumer) processEvents() {
 	}
 }
 
+// Events whose key doesn't pass key_filters are kept from the handler, reports whether e was one
+func (c *Consumer) filterDcpEvent(e *cb.DcpEvent) bool {
+	if c.keyFilters.Allows(e.Key) {
+		return false
+	}
+
+	switch e.Opcode {
+	case mcd.DCP_MUTATION:
+		c.dcpMutationFilteredCounter++
+	case mcd.DCP_DELETION:
+		c.dcpDeletionFilteredCounter++
+	case mcd.DCP_EXPIRATION:
+		c.dcpExpirationFilteredCounter++
+	}
+
+	c.skipDcpEvent(e)
+	return true
+}
+
+// Keeps the xattr in frameData, laid out as name, NUL and value, when include_xattrs names it.
+// Returns xattrs, allocated on the first xattr kept.
+func (c *Consumer) collectXattr(frameData []byte, xattrs map[string]json.RawMessage) map[string]json.RawMessage {
+	if c.includeXattrs == nil {
+		return xattrs
+	}
+
+	sep := bytes.IndexByte(frameData, 0)
+	if sep < 0 {
+		return xattrs
+	}
+
+	name, value := string(frameData[:sep]), frameData[sep+1:]
+	if !util.Contains(name, c.includeXattrs) || !json.Valid(value) {
+		return xattrs
+	}
+
+	if xattrs == nil {
+		xattrs = make(map[string]json.RawMessage)
+	}
+	xattrs[name] = json.RawMessage(value)
+	return xattrs
+}
+
+// Decodes xattrs laid out in io-vector format ahead of the value of e, and strips them off it.
+// Returns eventing xattr metadata, left empty if there's none, and xattrs named by include_xattrs.
+func (c *Consumer) decodeXattrs(e *cb.DcpEvent) (xMeta xattrMetadata, xattrs map[string]json.RawMessage) {
+	logPrefix := "Consumer::decodeXattrs"
+
+	totalXattrLen := binary.BigEndian.Uint32(e.Value[0:])
+	totalXattrData := e.Value[4 : 4+totalXattrLen-1]
+
+	logging.Tracef("%s [%s:%s:%d] key: %ru totalXattrLen: %v totalXattrData: %ru",
+		logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), totalXattrLen, totalXattrData)
+
+	var bytesDecoded uint32
+
+	// Try decoding all xattrs defined in io-vector encoding format
+	for bytesDecoded < totalXattrLen {
+		frameLength := binary.BigEndian.Uint32(totalXattrData)
+		bytesDecoded += 4
+		frameData := totalXattrData[4 : 4+frameLength-1]
+		bytesDecoded += frameLength
+		if bytesDecoded < totalXattrLen {
+			totalXattrData = totalXattrData[4+frameLength:]
+		}
+
+		xattrs = c.collectXattr(frameData, xattrs)
+
+		if len(frameData) > len(xattrPrefix) {
+			if bytes.Compare(frameData[:len(xattrPrefix)], []byte(xattrPrefix)) == 0 {
+				toParse := frameData[len(xattrPrefix)+1:]
+
+				err := json.Unmarshal(toParse, &xMeta)
+				if err != nil {
+					continue
+				}
+			}
+		}
+	}
+
+	logging.Tracef("%s [%s:%s:%d] Key: %ru xmeta dump: %ru",
+		logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), fmt.Sprintf("%#v", xMeta))
+
+	e.Value = e.Value[4+totalXattrLen:]
+	return
+}
+
+// Mutations made by the handler itself carry eventing xattr with their cas, or with crc of the
+// value for when cas has since moved on due to an xattr only update. Reports whether e is one,
+// storing its timers in plasma instead of it being handed to the handler again. Expects xattrs
+// to have been stripped off e.Value.
+func (c *Consumer) isRecursiveMutation(e *cb.DcpEvent, xMeta xattrMetadata) bool {
+	logPrefix := "Consumer::isRecursiveMutation"
+
+	// Validating for eventing xattr fields
+	if xMeta.Cas == "" {
+		return false
+	}
+
+	cas, err := util.ConvertBigEndianToUint64([]byte(xMeta.Cas))
+	if err != nil {
+		logging.Errorf("%s [%s:%s:%d] Key: %ru failed to convert cas string from kv to uint64, err: %v",
+			logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), err)
+		return true
+	}
+
+	logging.Tracef("%s [%s:%s:%d] Key: %ru decoded cas: %v dcp cas: %v",
+		logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), cas, e.Cas)
+
+	// Send mutation to V8 CPP worker _only_ when DcpEvent.Cas != Cas field in xattr
+	if cas != e.Cas && crc32.Update(0, c.crcTable, e.Value) != xMeta.Digest {
+		logging.Tracef("%s [%s:%s:%d] Key: %ru cas & crc have mismatched",
+			logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key))
+		return false
+	}
+
+	// Enabling it until MB-28779 gets resolved
+	for _, timerEntry := range xMeta.Timers {
+
+		data := strings.Split(timerEntry, "::")
+
+		if len(data) == 3 {
+			pEntry := &plasmaStoreEntry{
+				callbackFn: data[2],
+				key:        string(e.Key),
+				timerTs:    data[1],
+				vb:         e.VBucket,
+			}
+
+			c.plasmaStoreCh <- pEntry
+			logging.Tracef("%s [%s:%s:%d] Sending key: %ru to be stored in plasma, timer entry: %v pEntry: %#v",
+				logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), timerEntry, pEntry)
+		}
+	}
+
+	logging.Tracef("%s [%s:%s:%d] Skipping recursive mutation for key: %ru vb: %v, xmeta: %ru",
+		logPrefix, c.workerName, c.tcpPort, c.Pid(), string(e.Key), e.VBucket, fmt.Sprintf("%#v", xMeta))
+	return true
+}
+
+// Strips xattrs off the value of a mutation of a non-JSON document, returning those named by
+// include_xattrs. Reports whether it's to be sent to the handler, which it isn't if made by the
+// handler itself. Not subject to source_filter, which applies to fields of JSON documents.
+func (c *Consumer) prepareBinaryMutation(e *cb.DcpEvent) (xattrs map[string]json.RawMessage, send bool) {
+	if e.Datatype == dcpDatatypeBinaryXattr {
+		var xMeta xattrMetadata
+		xMeta, xattrs = c.decodeXattrs(e)
+		if c.isRecursiveMutation(e, xMeta) {
+			return nil, false
+		}
+	}
+
+	c.dcpMutationCounter++
+	c.dcpBinaryMutationCounter++
+	return xattrs, true
+}
+
+// Inflates value of e, which includes xattrs if any, and clears the snappy bit of its datatype
+func decompressDcpValue(e *cb.DcpEvent) error {
+	value, err := snappy.Decode(nil, e.Value)
+	if err != nil {
+		return err
+	}
+
+	e.Value = value
+	e.Datatype &^= dcpDatatypeSnappy
+	return nil
+}
+
+// Mutations whose document doesn't satisfy source_filter are kept from the handler, reports
+// whether e was one. Expects e.Value to be the document, with any xattrs stripped.
+func (c *Consumer) filterDcpMutationBySource(e *cb.DcpEvent) bool {
+	if c.sourceFilter.Matches(e.Value) {
+		return false
+	}
+
+	c.dcpSourceFilteredCounter++
+
+	c.skipDcpEvent(e)
+	return true
+}
+
+// Lets the worker advance its seq no past an event kept from the handler
+func (c *Consumer) skipDcpEvent(e *cb.DcpEvent) {
+	if !c.sendMsgToDebugger {
+		c.sendFilteredDcpEvent(e, c.sendMsgToDebugger)
+	} else {
+		go c.sendFilteredDcpEvent(e, c.sendMsgToDebugger)
+	}
+}
+
+// Accounts an event sent to the worker against max_events_per_sec
+func (c *Consumer) throttleDcpEvent() {
+	c.dcpEventsThrottled += c.dcpEventRateLimiter.Take(1)
+}
+
+// Accounts n timer events sent to the worker against max_timer_events_per_sec
+func (c *Consumer) throttleTimerEvents(n int) {
+	c.timerEventsThrottled += c.timerEventRateLimiter.Take(n)
+}
+
+// Returns feeds processEvents should pick events from, a feed is left out, i.e. nil, while
+// the function is over its rate limit, so that stop and other control events aren't held up.
+// throttleCh fires once the first of the feeds left out is within its rate limit again.
+func (c *Consumer) throttledFeeds() (aggDCPFeed chan *cb.DcpEvent, docTimerEntryCh chan *byTimer,
+	cronTimerEntryCh chan *timerMsg, throttleCh <-chan time.Time) {
+
+	aggDCPFeed, docTimerEntryCh, cronTimerEntryCh = c.aggDCPFeed, c.docTimerEntryCh, c.cronTimerEntryCh
+
+	dcpDelay := c.dcpEventRateLimiter.Delay()
+	if dcpDelay > 0 {
+		aggDCPFeed = nil
+	}
+
+	timerDelay := c.timerEventRateLimiter.Delay()
+	if timerDelay > 0 {
+		docTimerEntryCh, cronTimerEntryCh = nil, nil
+	}
+
+	switch {
+	case dcpDelay > 0 && (timerDelay == 0 || dcpDelay < timerDelay):
+		throttleCh = time.After(dcpDelay)
+	case timerDelay > 0:
+		throttleCh = time.After(timerDelay)
+	}
+	return
+}
+
 func (c *Consumer) startDcp(flogs couchbase.FailoverLog) {
 	logPrefix := "Consumer::startDcp"
 
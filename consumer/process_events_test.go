@@ -0,0 +1,190 @@
+package consumer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"testing"
+
+	"github.com/couchbase/eventing/dcp/transport/client"
+	"github.com/golang/snappy"
+)
+
+func TestCollectXattr(t *testing.T) {
+	tests := []struct {
+		name          string
+		includeXattrs []string
+		frames        []string // Name, NUL and value of each xattr
+		want          map[string]json.RawMessage
+	}{
+		{
+			name:   "include_xattrs not set",
+			frames: []string{"app\x00{\"a\":1}"},
+			want:   nil,
+		},
+		{
+			name:          "none included",
+			includeXattrs: []string{},
+			frames:        []string{"app\x00{\"a\":1}"},
+			want:          nil,
+		},
+		{
+			name:          "included ones kept",
+			includeXattrs: []string{"app", "audit"},
+			frames:        []string{"app\x00{\"a\":1}", "other\x00true", "audit\x00\"by\""},
+			want: map[string]json.RawMessage{
+				"app":   json.RawMessage(`{"a":1}`),
+				"audit": json.RawMessage(`"by"`),
+			},
+		},
+		{
+			name:          "system xattr not named",
+			includeXattrs: []string{"app"},
+			frames:        []string{"_eventing\x00{\"cas\":\"1\"}", "app\x001"},
+			want:          map[string]json.RawMessage{"app": json.RawMessage(`1`)},
+		},
+		{
+			name:          "invalid json value dropped",
+			includeXattrs: []string{"app"},
+			frames:        []string{"app\x00{\"a\":"},
+			want:          nil,
+		},
+		{
+			name:          "no separator",
+			includeXattrs: []string{"app"},
+			frames:        []string{"app"},
+			want:          nil,
+		},
+		{
+			name:          "name is a prefix of an included one",
+			includeXattrs: []string{"application"},
+			frames:        []string{"app\x00{}"},
+			want:          nil,
+		},
+	}
+
+	for _, test := range tests {
+		c := &Consumer{includeXattrs: test.includeXattrs}
+
+		var got map[string]json.RawMessage
+		for _, frame := range test.frames {
+			got = c.collectXattr([]byte(frame), got)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// Lays out xattrs, each being name, NUL and value, in io-vector format ahead of doc
+func withXattrs(doc string, xattrs ...string) []byte {
+	var frames []byte
+	for _, xattr := range xattrs {
+		frame := make([]byte, 4, 4+len(xattr)+1)
+		binary.BigEndian.PutUint32(frame, uint32(len(xattr)+1))
+		frames = append(frames, append(append(frame, xattr...), 0)...)
+	}
+
+	value := make([]byte, 4, 4+len(frames)+len(doc))
+	binary.BigEndian.PutUint32(value, uint32(len(frames)))
+	return append(append(value, frames...), doc...)
+}
+
+func TestPrepareBinaryMutation(t *testing.T) {
+	crcTable := crc32.MakeTable(crc32.Castagnoli)
+	doc := "\x00\x01binary"
+	digest := crc32.Update(0, crcTable, []byte(doc))
+
+	// Eventing xattr carries cas in little endian hex
+	handlerXattr := func(cas string, digest uint32) string {
+		return fmt.Sprintf("eventing\x00{\"cas\":\"%s\",\"digest\":%d}", cas, digest)
+	}
+
+	tests := []struct {
+		name       string
+		datatype   uint8
+		value      []byte
+		wantSend   bool
+		wantXattrs map[string]json.RawMessage
+	}{
+		{"no xattrs", dcpDatatypeBinary, []byte(doc), true, nil},
+		{"user xattrs", dcpDatatypeBinaryXattr, withXattrs(doc, "app\x00{\"a\":1}", "other\x00true"), true,
+			map[string]json.RawMessage{"app": json.RawMessage(`{"a":1}`)}},
+		{"made by handler", dcpDatatypeBinaryXattr, withXattrs(doc, handlerXattr("0x0100000000000000", 0)), false, nil},
+		{"made by handler and xattr updated since", dcpDatatypeBinaryXattr,
+			withXattrs(doc, handlerXattr("0x0200000000000000", digest)), false, nil},
+		{"changed since made by handler", dcpDatatypeBinaryXattr,
+			withXattrs(doc, handlerXattr("0x0200000000000000", digest+1), "app\x00[]"), true,
+			map[string]json.RawMessage{"app": json.RawMessage(`[]`)}},
+	}
+
+	for _, test := range tests {
+		c := &Consumer{includeXattrs: []string{"app"}, crcTable: crcTable}
+		e := &memcached.DcpEvent{Key: []byte("doc"), Cas: 1, Datatype: test.datatype, Value: test.value}
+
+		xattrs, send := c.prepareBinaryMutation(e)
+		if send != test.wantSend {
+			t.Errorf("%s: got send %v want %v", test.name, send, test.wantSend)
+		}
+
+		if !reflect.DeepEqual(xattrs, test.wantXattrs) {
+			t.Errorf("%s: got xattrs %v want %v", test.name, xattrs, test.wantXattrs)
+		}
+
+		if string(e.Value) != doc {
+			t.Errorf("%s: got value %q want %q", test.name, e.Value, doc)
+		}
+
+		var wantCount uint64
+		if test.wantSend {
+			wantCount = 1
+		}
+		if c.dcpBinaryMutationCounter != wantCount || c.dcpMutationCounter != wantCount {
+			t.Errorf("%s: got binary mutation count %d and mutation count %d want %d",
+				test.name, c.dcpBinaryMutationCounter, c.dcpMutationCounter, wantCount)
+		}
+	}
+}
+
+func TestDecompressDcpValue(t *testing.T) {
+	doc := []byte(`{"type":"order","lines":["a","a","a","a"]}`)
+	xattrValue := withXattrs(string(doc), "app\x00{}")
+
+	tests := []struct {
+		name         string
+		datatype     uint8
+		value        []byte
+		wantErr      bool
+		wantDatatype uint8
+		wantValue    []byte
+	}{
+		{"json", dcpDatatypeJSON | dcpDatatypeSnappy, snappy.Encode(nil, doc), false, dcpDatatypeJSON, doc},
+		{"json with xattrs", dcpDatatypeJSONXattr | dcpDatatypeSnappy, snappy.Encode(nil, xattrValue), false,
+			dcpDatatypeJSONXattr, xattrValue},
+		{"binary", dcpDatatypeBinary | dcpDatatypeSnappy, snappy.Encode(nil, []byte{0, 1, 2}), false,
+			dcpDatatypeBinary, []byte{0, 1, 2}},
+		{"corrupt", dcpDatatypeJSON | dcpDatatypeSnappy, doc, true, dcpDatatypeJSON | dcpDatatypeSnappy, doc},
+		{"truncated", dcpDatatypeJSON | dcpDatatypeSnappy, snappy.Encode(nil, doc)[:10], true,
+			dcpDatatypeJSON | dcpDatatypeSnappy, snappy.Encode(nil, doc)[:10]},
+	}
+
+	for _, test := range tests {
+		e := &memcached.DcpEvent{Datatype: test.datatype, Value: test.value}
+
+		err := decompressDcpValue(e)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: got err %v want err %v", test.name, err, test.wantErr)
+		}
+
+		if e.Datatype != test.wantDatatype {
+			t.Errorf("%s: got datatype %d want %d", test.name, e.Datatype, test.wantDatatype)
+		}
+
+		if !reflect.DeepEqual(e.Value, test.wantValue) {
+			t.Errorf("%s: got value %q want %q", test.name, e.Value, test.wantValue)
+		}
+	}
+}
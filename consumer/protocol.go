@@ -53,6 +53,8 @@ const (
 	dcpOpcode int8 = iota
 	dcpDeletion
 	dcpMutation
+	dcpFiltered
+	dcpExpiration
 )
 
 const (
@@ -108,6 +110,14 @@ func (c *Consumer) makeDcpDeletionHeader(partition int16, deletionMeta string) (
 	return c.makeDcpHeader(dcpDeletion, partition, deletionMeta)
 }
 
+func (c *Consumer) makeDcpExpirationHeader(partition int16, expirationMeta string) ([]byte, *flatbuffers.Builder) {
+	return c.makeDcpHeader(dcpExpiration, partition, expirationMeta)
+}
+
+func (c *Consumer) makeDcpFilteredHeader(partition int16, filteredMeta string) ([]byte, *flatbuffers.Builder) {
+	return c.makeDcpHeader(dcpFiltered, partition, filteredMeta)
+}
+
 func (c *Consumer) makeDcpHeader(opcode int8, partition int16, meta string) ([]byte, *flatbuffers.Builder) {
 	return c.makeHeader(dcpEvent, opcode, partition, meta)
 }
@@ -0,0 +1,46 @@
+package consumer
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/couchbase/eventing/gen/flatbuf/header"
+	"github.com/google/flatbuffers/go"
+)
+
+func TestMakeDcpHeader(t *testing.T) {
+	c := &Consumer{}
+	c.builderPool = &sync.Pool{
+		New: func() interface{} {
+			return flatbuffers.NewBuilder(0)
+		},
+	}
+
+	tests := []struct {
+		name       string
+		makeHeader func(int16, string) ([]byte, *flatbuffers.Builder)
+		wantOpcode int8
+	}{
+		{"mutation", c.makeDcpMutationHeader, dcpMutation},
+		{"deletion", c.makeDcpDeletionHeader, dcpDeletion},
+		{"expiration", c.makeDcpExpirationHeader, dcpExpiration},
+	}
+
+	meta := `{"id":"doc","vb":5,"seq":7,"expiration":1500000000}`
+	for _, test := range tests {
+		encoded, builder := test.makeHeader(5, meta)
+
+		h := header.GetRootAsHeader(encoded, 0)
+		if h.Event() != dcpEvent || h.Opcode() != test.wantOpcode {
+			t.Errorf("%s: got event %d opcode %d want %d and %d",
+				test.name, h.Event(), h.Opcode(), dcpEvent, test.wantOpcode)
+		}
+
+		if h.Partition() != 5 || string(h.Metadata()) != meta {
+			t.Errorf("%s: got partition %d meta %s want 5 and %s",
+				test.name, h.Partition(), h.Metadata(), meta)
+		}
+
+		c.putBuilder(builder)
+	}
+}
@@ -95,6 +95,10 @@ func (c *Consumer) processDocTimerEvents() {
 		case <-timerProcessingTicker.C:
 		}
 
+		if c.processingPaused() {
+			continue
+		}
+
 		vbsOwned := c.getCurrentlyOwnedVbs()
 		for _, vb := range vbsOwned {
 			currTimer := c.vbProcessingStats.getVbStat(vb, "currently_processed_doc_id_timer").(string)
@@ -394,6 +398,10 @@ func (c *Consumer) processCronTimerEvents() {
 			return
 
 		case <-c.cronTimerProcessingTicker.C:
+			if c.processingPaused() {
+				continue
+			}
+
 			vbsOwned := c.getVbsOwned()
 
 			for _, vb := range vbsOwned {
@@ -25,7 +25,8 @@ import (
 func NewConsumer(hConfig *common.HandlerConfig, pConfig *common.ProcessConfig, rConfig *common.RebalanceConfig,
 	index int, uuid string, eventingNodeUUIDs []string, vbnos []uint16, app *common.AppConfig,
 	dcpConfig map[string]interface{}, p common.EventingProducer, s common.EventingSuperSup, vbPlasmaStore *plasma.Plasma,
-	iteratorRefreshCounter, numVbuckets int) *Consumer {
+	iteratorRefreshCounter, numVbuckets int, dcpEventRateLimiter, timerEventRateLimiter *util.RateLimiter) *Consumer {
+	logPrefix := "Consumer::NewConsumer"
 
 	var b *couchbase.Bucket
 	consumer := &Consumer{
@@ -38,6 +39,7 @@ func NewConsumer(hConfig *common.HandlerConfig, pConfig *common.ProcessConfig, r
 		checkpointInterval:              time.Duration(hConfig.CheckpointInterval) * time.Millisecond,
 		cleanupCronTimerCh:              make(chan *cronTimerToCleanup, dcpConfig["genChanSize"].(int)),
 		cleanupCronTimerStopCh:          make(chan struct{}, 1),
+		binaryDocuments:                 hConfig.BinaryDocuments,
 		cleanupTimers:                   hConfig.CleanupTimers,
 		clusterStateChangeNotifCh:       make(chan struct{}, ClusterChangeNotifChBufSize),
 		connMutex:                       &sync.RWMutex{},
@@ -67,8 +69,11 @@ func NewConsumer(hConfig *common.HandlerConfig, pConfig *common.ProcessConfig, r
 		feedbackTCPPort:                 pConfig.FeedbackSockIdentifier,
 		feedbackWriteBatchSize:          hConfig.FeedbackBatchSize,
 		fuzzOffset:                      hConfig.FuzzOffset,
+		includeXattrs:                   hConfig.IncludeXattrs,
+		keyFilters:                      hConfig.KeyFilters,
 		gracefulShutdownChan:            make(chan struct{}, 1),
 		ipcType:                         pConfig.IPCType,
+		isProcessingPaused:              hConfig.ProcessingPaused,
 		iteratorRefreshCounter:          iteratorRefreshCounter,
 		hostDcpFeedRWMutex:              &sync.RWMutex{},
 		kvHostDcpFeedMap:                make(map[string]*couchbase.DcpFeed),
@@ -120,6 +125,8 @@ func NewConsumer(hConfig *common.HandlerConfig, pConfig *common.ProcessConfig, r
 		vbOwnershipGiveUpRoutineCount:   rConfig.VBOwnershipGiveUpRoutineCount,
 		vbOwnershipTakeoverRoutineCount: rConfig.VBOwnershipTakeoverRoutineCount,
 		vbPlasmaStore:                   vbPlasmaStore,
+		dcpEventRateLimiter:             dcpEventRateLimiter,
+		timerEventRateLimiter:           timerEventRateLimiter,
 		vbProcessingStats:               newVbProcessingStats(app.AppName, uint16(numVbuckets)),
 		vbsRemainingToGiveUp:            make([]uint16, 0),
 		vbsRemainingToOwn:               make([]uint16, 0),
@@ -139,6 +146,14 @@ func NewConsumer(hConfig *common.HandlerConfig, pConfig *common.ProcessConfig, r
 		},
 	}
 
+	// Evaluation context of a filter isn't safe for concurrent use, so consumers don't share one.
+	// Producer has already parsed the same expression, so this isn't expected to fail.
+	sourceFilter, err := util.NewSourceFilter(hConfig.SourceFilter)
+	if err != nil {
+		logging.Errorf("%s [%s] Failed to parse source_filter, err: %v", logPrefix, consumer.workerName, err)
+	}
+	consumer.sourceFilter = sourceFilter
+
 	return consumer
 }
 
@@ -210,7 +225,17 @@ func (c *Consumer) Serve() {
 
 	c.doCleanupForPreviouslyOwnedVbs()
 
-	c.startDcp(flogs)
+	// Streams are requested on resume when the function was paused before consumer bootstrap
+	c.Lock()
+	c.isStartDcpPending = c.isProcessingPaused
+	c.Unlock()
+
+	if c.isStartDcpPending {
+		logging.Infof("%s [%s:%s:%d] Processing is paused, deferring dcp streams till resume",
+			logPrefix, c.workerName, c.tcpPort, c.Pid())
+	} else {
+		c.startDcp(flogs)
+	}
 
 	logging.Infof("%s [%s:%s:%d] docCurrTimer: %s docNextTimer: %v cronCurrTimer: %v cronNextTimer: %v",
 		logPrefix, c.workerName, c.tcpPort, c.Pid(), c.docCurrTimer, c.docNextTimer, c.cronCurrTimer, c.cronNextTimer)
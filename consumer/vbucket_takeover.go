@@ -419,6 +419,14 @@ func (c *Consumer) updateVbOwnerAndStartDCPStream(vbKey string, vb uint16, vbBlo
 					return
 
 				case mcd.DCP_MUTATION:
+					if e.Datatype&dcpDatatypeSnappy != 0 {
+						if err := decompressDcpValue(e); err != nil {
+							logging.Errorf("%s [%s:%s:%d] vb: %v key: %ru failed to decompress snappy value, err: %v",
+								logPrefix, c.workerName, c.tcpPort, c.Pid(), vb, string(e.Key), err)
+							continue
+						}
+					}
+
 					switch e.Datatype {
 					case dcpDatatypeJSONXattr:
 						totalXattrLen := binary.BigEndian.Uint32(e.Value[0:])
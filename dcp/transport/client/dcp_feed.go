@@ -20,6 +20,7 @@ const opaqueOpen = 0xBEAF0001
 const opaqueFailover = 0xDEADBEEF
 const opaqueGetseqno = 0xDEADBEEF
 const openConnFlag = uint32(0x1)
+const openConnIncludeDeleteTimes = uint32(0x20)
 
 // error codes
 var ErrorInvalidLog = errors.New("couchbase.errorInvalidLog")
@@ -46,6 +47,7 @@ type DcpFeed struct {
 	maxAckBytes uint32   // Max buffer control ack bytes
 	stats       DcpStats // Stats for dcp client
 	dcplatency  *Average
+	snappy      bool // Producer may send values snappy compressed
 }
 
 // NewDcpFeed creates a new DCP Feed.
@@ -489,6 +491,10 @@ func (feed *DcpFeed) doDcpOpen(
 	opaque uint16,
 	rcvch chan []interface{}) error {
 
+	if err := feed.doHello(name, opaque, rcvch); err != nil {
+		return err
+	}
+
 	rq := &transport.MCRequest{
 		Opcode: transport.DCP_OPEN,
 		Key:    []byte(name),
@@ -638,6 +644,86 @@ func (feed *DcpFeed) doDcpOpen(
 		fmsg := "%v ##%x received response for set_noop_interval"
 		logging.Infof(fmsg, prefix, opaque)
 	}
+
+	// send a DCP control message to enable_expiry_opcode, producers not
+	// knowing of it only mean expirations come as deletions, hence its
+	// response status isn't fatal
+	if flags&openConnIncludeDeleteTimes != 0 {
+		rq := &transport.MCRequest{
+			Opcode: transport.DCP_CONTROL,
+			Key:    []byte("enable_expiry_opcode"),
+			Body:   []byte("true"),
+		}
+		if err := feed.conn.Transmit(rq); err != nil {
+			fmsg := "%v ##%x doDcpOpen.Transmit(enable_expiry_opcode): %v"
+			logging.Errorf(fmsg, prefix, opaque, err)
+			return err
+		}
+		logging.Infof("%v ##%x sending enable_expiry_opcode", prefix, opaque)
+		msg, ok := <-rcvch
+		if !ok {
+			fmsg := "%v ##%x doDcpOpen.rcvch (enable_expiry_opcode) closed"
+			logging.Errorf(fmsg, prefix, opaque)
+			return ErrorConnection
+		}
+		pkt := msg[0].(*transport.MCRequest)
+		opcode, status := pkt.Opcode, transport.Status(pkt.VBucket)
+		if opcode != transport.DCP_CONTROL {
+			fmsg := "%v ##%x DCP_CONTROL (enable_expiry_opcode) != #%v"
+			logging.Errorf(fmsg, prefix, opaque, opcode)
+			return ErrorConnection
+		} else if status != transport.SUCCESS {
+			fmsg := "%v ##%x doDcpOpen (enable_expiry_opcode) response status %v"
+			logging.Warnf(fmsg, prefix, opaque, status)
+		} else {
+			fmsg := "%v ##%x received response for enable_expiry_opcode"
+			logging.Infof(fmsg, prefix, opaque)
+		}
+	}
+	return nil
+}
+
+// doHello negotiates snappy with the producer, which has to happen before
+// DCP_OPEN. Producer not agreeing to it only means values come uncompressed.
+func (feed *DcpFeed) doHello(
+	name string, opaque uint16, rcvch chan []interface{}) error {
+
+	rq := &transport.MCRequest{
+		Opcode: transport.HELLO,
+		Key:    []byte(name),
+		Body:   make([]byte, 2),
+	}
+	binary.BigEndian.PutUint16(rq.Body, uint16(transport.FEATURE_SNAPPY))
+
+	prefix := feed.logPrefix
+	if err := feed.conn.Transmit(rq); err != nil {
+		fmsg := "%v ##%x doHello.Transmit(): %v"
+		logging.Errorf(fmsg, prefix, opaque, err)
+		return err
+	}
+	msg, ok := <-rcvch
+	if !ok {
+		logging.Errorf("%v ##%x doHello.rcvch closed", prefix, opaque)
+		return ErrorConnection
+	}
+	pkt := msg[0].(*transport.MCRequest)
+	opcode, status := pkt.Opcode, transport.Status(pkt.VBucket)
+	if opcode != transport.HELLO {
+		logging.Errorf("%v ##%x HELLO != #%v", prefix, opaque, opcode)
+		return ErrorConnection
+	} else if status != transport.SUCCESS {
+		fmsg := "%v ##%x doHello response status %v, values will be uncompressed"
+		logging.Warnf(fmsg, prefix, opaque, status)
+		return nil
+	}
+
+	// Body lists features the producer agreed to
+	for i := 0; i+2 <= len(pkt.Body); i += 2 {
+		if transport.Feature(binary.BigEndian.Uint16(pkt.Body[i:])) == transport.FEATURE_SNAPPY {
+			feed.snappy = true
+		}
+	}
+	logging.Infof("%v ##%x snappy negotiated: %v", prefix, opaque, feed.snappy)
 	return nil
 }
 
@@ -0,0 +1,57 @@
+package memcached
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/couchbase/eventing/dcp/transport"
+)
+
+func TestNewDcpEvent(t *testing.T) {
+	mutationExtras := make([]byte, 31)
+	binary.BigEndian.PutUint64(mutationExtras[0:], 7)
+	binary.BigEndian.PutUint64(mutationExtras[8:], 3)
+	binary.BigEndian.PutUint32(mutationExtras[20:], 1500000000)
+
+	// seqno, rev seqno and delete time, as sent with include delete times
+	deleteExtras := make([]byte, 20)
+	binary.BigEndian.PutUint64(deleteExtras[0:], 7)
+	binary.BigEndian.PutUint64(deleteExtras[8:], 3)
+	binary.BigEndian.PutUint32(deleteExtras[16:], 1500000000)
+
+	tests := []struct {
+		name       string
+		opcode     transport.CommandCode
+		extras     []byte
+		wantExpiry uint32
+	}{
+		{"mutation", transport.DCP_MUTATION, mutationExtras, 1500000000},
+		{"deletion", transport.DCP_DELETION, deleteExtras, 0},
+		{"expiration", transport.DCP_EXPIRATION, deleteExtras, 0},
+	}
+
+	stream := &DcpStream{Vbucket: 5, Vbuuid: 11}
+	for _, test := range tests {
+		rq := &transport.MCRequest{
+			Opcode: test.opcode,
+			Key:    []byte("doc"),
+			Extras: test.extras,
+			Opaque: 0x00120000,
+		}
+
+		event := newDcpEvent(rq, stream)
+		if event.Opcode != test.opcode || string(event.Key) != "doc" {
+			t.Errorf("%s: got opcode %v key %s want %v and doc", test.name, event.Opcode, event.Key, test.opcode)
+		}
+
+		if event.Seqno != 7 || event.RevSeqno != 3 || event.Expiry != test.wantExpiry {
+			t.Errorf("%s: got seqno %d rev seqno %d expiry %d want 7, 3 and %d",
+				test.name, event.Seqno, event.RevSeqno, event.Expiry, test.wantExpiry)
+		}
+
+		if event.VBucket != 5 || event.VBuuid != 11 || event.Opaque != 0x12 {
+			t.Errorf("%s: got vb %d vbuuid %d opaque %x want 5, 11 and 12",
+				test.name, event.VBucket, event.VBuuid, event.Opaque)
+		}
+	}
+}
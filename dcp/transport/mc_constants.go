@@ -41,6 +41,7 @@ const (
 	FLUSHQ     = CommandCode(0x18)
 	APPENDQ    = CommandCode(0x19)
 	PREPENDQ   = CommandCode(0x1a)
+	HELLO      = CommandCode(0x1f)
 	RGET       = CommandCode(0x30)
 	RSET       = CommandCode(0x31)
 	RSETQ      = CommandCode(0x32)
@@ -89,6 +90,13 @@ const (
 	OBSERVE = CommandCode(0x92)
 )
 
+// Feature negotiated through HELLO.
+type Feature uint16
+
+const (
+	FEATURE_SNAPPY = Feature(0x0a) // Values may be sent snappy compressed
+)
+
 // Status field for memcached response.
 type Status uint16
 
@@ -153,6 +161,7 @@ func init() {
 	CommandNames[FLUSHQ] = "FLUSHQ"
 	CommandNames[APPENDQ] = "APPENDQ"
 	CommandNames[PREPENDQ] = "PREPENDQ"
+	CommandNames[HELLO] = "HELLO"
 	CommandNames[RGET] = "RGET"
 	CommandNames[RSET] = "RSET"
 	CommandNames[RSETQ] = "RSETQ"
@@ -8,6 +8,7 @@ import (
 
 	"github.com/couchbase/eventing/common"
 	"github.com/couchbase/eventing/suptree"
+	"github.com/couchbase/eventing/util"
 	"github.com/couchbase/gocb"
 	"github.com/couchbase/plasma"
 )
@@ -29,6 +30,8 @@ const (
 
 	supervisorTimeout = 60 * time.Second
 
+	appLogSubscriberBufSize = 1000
+
 	// KV blob suffixes to assist in choose right consumer instance
 	// for instantiating V8 Debugger instance
 	startDebuggerFlag    = "startDebugger"
@@ -82,6 +85,15 @@ type Producer struct {
 	appLogMaxFiles int
 	appLogWriter   io.WriteCloser
 
+	// Token buckets throttling DCP and timer events, shared by all consumers of the producer
+	dcpEventRateLimiter   *util.RateLimiter
+	timerEventRateLimiter *util.RateLimiter
+
+	// Receive app log lines as they are written, for streaming them over REST
+	appLogSubscribers  map[int64]chan string
+	appLogSubscriberID int64
+	appLogSubsRWMutex  *sync.RWMutex
+
 	// Plasma configs
 	autoSwapper            bool
 	enableSnapshotSMR      bool
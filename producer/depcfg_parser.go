@@ -63,164 +63,77 @@ func (p *Producer) parseDepcfg() error {
 		return uErr
 	}
 
-	// Handler related configurations
-
-	if val, ok := settings["checkpoint_interval"]; ok {
-		p.handlerConfig.CheckpointInterval = int(val.(float64))
-	} else {
-		p.handlerConfig.CheckpointInterval = 60000
-	}
-
-	if val, ok := settings["cleanup_timers"]; ok {
-		p.handlerConfig.CleanupTimers = val.(bool)
-	} else {
-		p.handlerConfig.CleanupTimers = false
-	}
-
-	if val, ok := settings["cpp_worker_thread_count"]; ok {
-		p.handlerConfig.CPPWorkerThrCount = int(val.(float64))
-	} else {
-		p.handlerConfig.CPPWorkerThrCount = 2
-	}
-
-	if val, ok := settings["cron_timers_per_doc"]; ok {
-		p.handlerConfig.CronTimersPerDoc = int(val.(float64))
-	} else {
-		p.handlerConfig.CronTimersPerDoc = 1000
-	}
-
-	if val, ok := settings["curl_timeout"]; ok {
-		p.handlerConfig.CurlTimeout = int64(val.(float64))
-	} else {
-		p.handlerConfig.CurlTimeout = int64(500)
-	}
-
-	if val, ok := settings["dcp_stream_boundary"]; ok {
-		p.handlerConfig.StreamBoundary = common.DcpStreamBoundary(val.(string))
-	} else {
-		p.handlerConfig.StreamBoundary = common.DcpStreamBoundary("everything")
-	}
-
-	if val, ok := settings["deadline_timeout"]; ok {
-		p.handlerConfig.SocketTimeout = int(val.(float64))
-	} else {
-		p.handlerConfig.SocketTimeout = 2
-	}
-
-	if val, ok := settings["enable_recursive_mutation"]; ok {
-		p.handlerConfig.EnableRecursiveMutation = val.(bool)
-	} else {
-		p.handlerConfig.EnableRecursiveMutation = false
-	}
-
-	if val, ok := settings["execution_timeout"]; ok {
-		p.handlerConfig.ExecutionTimeout = int(val.(float64))
-	} else {
-		p.handlerConfig.ExecutionTimeout = 1
-	}
-
-	if val, ok := settings["feedback_batch_size"]; ok {
-		p.handlerConfig.FeedbackBatchSize = int(val.(float64))
-	} else {
-		p.handlerConfig.FeedbackBatchSize = 100
-	}
-
-	if val, ok := settings["feedback_read_buffer_size"]; ok {
-		p.handlerConfig.FeedbackReadBufferSize = int(val.(float64))
-	} else {
-		p.handlerConfig.FeedbackReadBufferSize = 65536
-	}
-
-	if val, ok := settings["fuzz_offset"]; ok {
-		p.handlerConfig.FuzzOffset = int(val.(float64))
-	} else {
-		p.handlerConfig.FuzzOffset = 0
-	}
-
-	if val, ok := settings["lcb_inst_capacity"]; ok {
-		p.handlerConfig.LcbInstCapacity = int(val.(float64))
-	} else {
-		p.handlerConfig.LcbInstCapacity = 5
-	}
-
-	if val, ok := settings["log_level"]; ok {
-		p.handlerConfig.LogLevel = val.(string)
-	} else {
-		p.handlerConfig.LogLevel = "INFO"
-	}
-
-	if val, ok := settings["skip_timer_threshold"]; ok {
-		p.handlerConfig.SkipTimerThreshold = int(val.(float64))
-	} else {
-		p.handlerConfig.SkipTimerThreshold = 86400
-	}
-
-	if val, ok := settings["sock_batch_size"]; ok {
-		p.handlerConfig.SocketWriteBatchSize = int(val.(float64))
-	} else {
-		p.handlerConfig.SocketWriteBatchSize = 100
-	}
-
-	if val, ok := settings["tick_duration"]; ok {
-		p.handlerConfig.StatsLogInterval = int(val.(float64))
-	} else {
-		p.handlerConfig.StatsLogInterval = 300 * 1000
-	}
-
-	if val, ok := settings["timer_processing_tick_interval"]; ok {
-		p.handlerConfig.TimerProcessingTickInterval = int(val.(float64))
-	} else {
-		p.handlerConfig.TimerProcessingTickInterval = 500
-	}
-
-	if val, ok := settings["worker_count"]; ok {
-		p.handlerConfig.WorkerCount = int(val.(float64))
-	} else {
-		p.handlerConfig.WorkerCount = 3
-	}
-
-	if val, ok := settings["worker_feedback_queue_cap"]; ok {
-		p.handlerConfig.FeedbackQueueCap = int64(val.(float64))
-	} else {
-		p.handlerConfig.FeedbackQueueCap = int64(10 * 1000)
-	}
-
-	if val, ok := settings["worker_queue_cap"]; ok {
-		p.handlerConfig.WorkerQueueCap = int64(val.(float64))
-	} else {
-		p.handlerConfig.WorkerQueueCap = int64(100 * 1000)
+	common.FillMissingSettings(settings)
+	if err := common.CheckSettingTypes(settings); err != nil {
+		logging.Errorf("%s [%s] Invalid settings received from metakv, err: %v", logPrefix, p.appName, err)
+		return err
 	}
 
-	if val, ok := settings["xattr_doc_timer_entry_prune_threshold"]; ok {
-		p.handlerConfig.XattrEntryPruneThreshold = int(val.(float64))
-	} else {
-		p.handlerConfig.XattrEntryPruneThreshold = 100
+	// Handler related configurations
+	p.handlerConfig.BinaryDocuments = settings["binary_documents"].(bool)
+	p.handlerConfig.CheckpointInterval = int(settings["checkpoint_interval"].(float64))
+	p.handlerConfig.CleanupTimers = settings["cleanup_timers"].(bool)
+	p.handlerConfig.CPPWorkerThrCount = int(settings["cpp_worker_thread_count"].(float64))
+	p.handlerConfig.CronTimersPerDoc = int(settings["cron_timers_per_doc"].(float64))
+	p.handlerConfig.CurlTimeout = int64(settings["curl_timeout"].(float64))
+	p.handlerConfig.StreamBoundary = common.DcpStreamBoundary(settings["dcp_stream_boundary"].(string))
+	p.handlerConfig.SocketTimeout = int(settings["deadline_timeout"].(float64))
+	p.handlerConfig.EnableRecursiveMutation = settings["enable_recursive_mutation"].(bool)
+	p.handlerConfig.ExecutionTimeout = int(settings["execution_timeout"].(float64))
+	p.handlerConfig.FeedbackBatchSize = int(settings["feedback_batch_size"].(float64))
+	p.handlerConfig.FeedbackReadBufferSize = int(settings["feedback_read_buffer_size"].(float64))
+	p.handlerConfig.FuzzOffset = int(settings["fuzz_offset"].(float64))
+
+	p.handlerConfig.IncludeXattrs = nil
+	if val, ok := settings["include_xattrs"]; ok {
+		p.handlerConfig.IncludeXattrs = make([]string, 0)
+		for _, xattr := range val.([]interface{}) {
+			p.handlerConfig.IncludeXattrs = append(p.handlerConfig.IncludeXattrs, xattr.(string))
+		}
+	}
+
+	p.handlerConfig.KeyFilters = nil
+	if val, ok := settings["key_filters"]; ok {
+		keyFilters, err := common.ParseKeyFilters(val)
+		if err != nil {
+			logging.Errorf("%s [%s] Failed to parse key_filters, err: %v", logPrefix, p.appName, err)
+			return err
+		}
+		p.handlerConfig.KeyFilters = keyFilters
+	}
+
+	p.handlerConfig.LcbInstCapacity = int(settings["lcb_inst_capacity"].(float64))
+	p.handlerConfig.LogLevel = settings["log_level"].(string)
+	p.handlerConfig.MaxEventsPerSec = int(settings["max_events_per_sec"].(float64))
+	p.handlerConfig.MaxTimerEventsPerSec = int(settings["max_timer_events_per_sec"].(float64))
+	p.handlerConfig.ProcessingPaused = settings["processing_paused"].(bool)
+	p.handlerConfig.SkipTimerThreshold = int(settings["skip_timer_threshold"].(float64))
+	p.handlerConfig.SocketWriteBatchSize = int(settings["sock_batch_size"].(float64))
+	p.handlerConfig.StatsLogInterval = int(settings["tick_duration"].(float64))
+	p.handlerConfig.TimerProcessingTickInterval = int(settings["timer_processing_tick_interval"].(float64))
+	p.handlerConfig.WorkerCount = int(settings["worker_count"].(float64))
+	p.handlerConfig.FeedbackQueueCap = int64(settings["worker_feedback_queue_cap"].(float64))
+	p.handlerConfig.WorkerQueueCap = int64(settings["worker_queue_cap"].(float64))
+	p.handlerConfig.XattrEntryPruneThreshold = int(settings["xattr_doc_timer_entry_prune_threshold"].(float64))
+
+	// Parsed here to fail early, each consumer parses its own as filters can't be shared
+	p.handlerConfig.SourceFilter = ""
+	if val, ok := settings["source_filter"]; ok {
+		if _, err := util.NewSourceFilter(val.(string)); err != nil {
+			logging.Errorf("%s [%s] Failed to parse source_filter, err: %v", logPrefix, p.appName, err)
+			return err
+		}
+		p.handlerConfig.SourceFilter = val.(string)
 	}
 
 	// Process related configuration
-
-	if val, ok := settings["breakpad_on"]; ok {
-		p.processConfig.BreakpadOn = val.(bool)
-	} else {
-		p.processConfig.BreakpadOn = false
-	}
+	p.processConfig.BreakpadOn = settings["breakpad_on"].(bool)
 
 	// Rebalance related configurations
-
-	if val, ok := settings["vb_ownership_giveup_routine_count"]; ok {
-		p.rebalanceConfig.VBOwnershipGiveUpRoutineCount = int(val.(float64))
-	} else {
-		p.rebalanceConfig.VBOwnershipGiveUpRoutineCount = 3
-	}
-
-	if val, ok := settings["vb_ownership_takeover_routine_count"]; ok {
-		p.rebalanceConfig.VBOwnershipTakeoverRoutineCount = int(val.(float64))
-	} else {
-		p.rebalanceConfig.VBOwnershipTakeoverRoutineCount = 3
-	}
+	p.rebalanceConfig.VBOwnershipGiveUpRoutineCount = int(settings["vb_ownership_giveup_routine_count"].(float64))
+	p.rebalanceConfig.VBOwnershipTakeoverRoutineCount = int(settings["vb_ownership_takeover_routine_count"].(float64))
 
 	// Application logging related configurations
-
 	if val, ok := settings["app_log_dir"]; ok {
 		os.MkdirAll(val.(string), 0755)
 		p.appLogPath = fmt.Sprintf("%s/%s", val.(string), p.appName)
@@ -229,105 +142,26 @@ func (p *Producer) parseDepcfg() error {
 		p.appLogPath = fmt.Sprintf("%s/%s.log", p.processConfig.EventingDir, p.appName)
 	}
 
-	if val, ok := settings["app_log_max_size"]; ok {
-		p.appLogMaxSize = int64(val.(float64))
-	} else {
-		p.appLogMaxSize = 1024 * 1024 * 10
-	}
-
-	if val, ok := settings["app_log_max_files"]; ok {
-		p.appLogMaxFiles = int(val.(float64))
-	} else {
-		p.appLogMaxFiles = 10
-	}
+	p.appLogMaxSize = int64(settings["app_log_max_size"].(float64))
+	p.appLogMaxFiles = int(settings["app_log_max_files"].(float64))
 
 	// Doc timer configurations for plasma
-
-	if val, ok := settings["auto_swapper"]; ok {
-		p.autoSwapper = val.(bool)
-	} else {
-		p.autoSwapper = true
-	}
-
-	if val, ok := settings["enable_snapshot_smr"]; ok {
-		p.enableSnapshotSMR = val.(bool)
-	} else {
-		p.enableSnapshotSMR = false
-	}
-
-	if val, ok := settings["iterator_refresh_counter"]; ok {
-		p.iteratorRefreshCounter = val.(int)
-	} else {
-		p.iteratorRefreshCounter = 10 * 1000
-	}
-
-	if val, ok := settings["lss_cleaner_max_threshold"]; ok {
-		p.lssCleanerMaxThreshold = int(val.(float64))
-	} else {
-		p.lssCleanerMaxThreshold = 70
-	}
-
-	if val, ok := settings["lss_cleaner_threshold"]; ok {
-		p.lssCleanerThreshold = int(val.(float64))
-	} else {
-		p.lssCleanerThreshold = 30
-	}
-
-	if val, ok := settings["lss_read_ahead_size"]; ok {
-		p.lssReadAheadSize = int64(val.(float64))
-	} else {
-		p.lssReadAheadSize = 1024 * 1024
-	}
-
-	if val, ok := settings["max_delta_chain_len"]; ok {
-		p.maxDeltaChainLen = int(val.(float64))
-	} else {
-		p.maxDeltaChainLen = 200
-	}
-
-	if val, ok := settings["max_page_items"]; ok {
-		p.maxPageItems = int(val.(float64))
-	} else {
-		p.maxPageItems = 400
-	}
-
-	if val, ok := settings["min_page_items"]; ok {
-		p.minPageItems = int(val.(float64))
-	} else {
-		p.minPageItems = 50
-	}
-
-	if val, ok := settings["persist_interval"]; ok {
-		p.persistInterval = int(val.(float64))
-	} else {
-		p.persistInterval = 5000
-	}
-
-	if val, ok := settings["use_memory_manager"]; ok {
-		p.useMemoryMgmt = val.(bool)
-	} else {
-		p.useMemoryMgmt = true
-	}
+	p.autoSwapper = settings["auto_swapper"].(bool)
+	p.enableSnapshotSMR = settings["enable_snapshot_smr"].(bool)
+	p.iteratorRefreshCounter = int(settings["iterator_refresh_counter"].(float64))
+	p.lssCleanerMaxThreshold = int(settings["lss_cleaner_max_threshold"].(float64))
+	p.lssCleanerThreshold = int(settings["lss_cleaner_threshold"].(float64))
+	p.lssReadAheadSize = int64(settings["lss_read_ahead_size"].(float64))
+	p.maxDeltaChainLen = int(settings["max_delta_chain_len"].(float64))
+	p.maxPageItems = int(settings["max_page_items"].(float64))
+	p.minPageItems = int(settings["min_page_items"].(float64))
+	p.persistInterval = int(settings["persist_interval"].(float64))
+	p.useMemoryMgmt = settings["use_memory_manager"].(bool)
 
 	// DCP connection related configurations
-
-	if val, ok := settings["data_chan_size"]; ok {
-		p.dcpConfig["dataChanSize"] = int(val.(float64))
-	} else {
-		p.dcpConfig["dataChanSize"] = 10000
-	}
-
-	if val, ok := settings["dcp_gen_chan_size"]; ok {
-		p.dcpConfig["genChanSize"] = int(val.(float64))
-	} else {
-		p.dcpConfig["genChanSize"] = 10000
-	}
-
-	if val, ok := settings["dcp_num_connections"]; ok {
-		p.dcpConfig["numConnections"] = int(val.(float64))
-	} else {
-		p.dcpConfig["numConnections"] = 1
-	}
+	p.dcpConfig["dataChanSize"] = int(settings["data_chan_size"].(float64))
+	p.dcpConfig["genChanSize"] = int(settings["dcp_gen_chan_size"].(float64))
+	p.dcpConfig["numConnections"] = int(settings["dcp_num_connections"].(float64))
 
 	p.dcpConfig["activeVbOnly"] = true
 
@@ -120,6 +120,21 @@ func (p *Producer) GetAppCode() string {
 	return p.app.AppCode
 }
 
+// GetEventsProcessedPSec returns aggregate of dcp/timer events processed per sec by all running consumers
+func (p *Producer) GetEventsProcessedPSec() *common.EventProcessingStats {
+	eventsProcessedPSec := &common.EventProcessingStats{}
+
+	for _, consumer := range p.runningConsumers {
+		pStats := consumer.EventsProcessedPSec()
+
+		eventsProcessedPSec.DcpEventsProcessedPSec += pStats.DcpEventsProcessedPSec
+		eventsProcessedPSec.TimerEventsProcessedPSec += pStats.TimerEventsProcessedPSec
+	}
+	eventsProcessedPSec.Timestamp = time.Now().Format("2006-01-02T15:04:05Z")
+
+	return eventsProcessedPSec
+}
+
 // GetEventProcessingStats exposes dcp/timer processing stats
 func (p *Producer) GetEventProcessingStats() map[string]uint64 {
 	aggStats := make(map[string]uint64)
@@ -338,6 +353,24 @@ func (p *Producer) GetEventingConsumerPids() map[string]int {
 	return workerPidMapping
 }
 
+// GetWorkerStats returns stats of each running Eventing.Consumer instance, keyed by worker name
+func (p *Producer) GetWorkerStats() map[string]*common.WorkerStats {
+	workerStats := make(map[string]*common.WorkerStats)
+
+	for _, consumer := range p.runningConsumers {
+		workerStats[consumer.ConsumerName()] = &common.WorkerStats{
+			DcpEventsRemaining:   consumer.DcpEventsRemainingToProcess(),
+			EventProcessingStats: consumer.GetEventProcessingStats(),
+			ExecutionStats:       consumer.GetExecutionStats(),
+			FailureStats:         consumer.GetFailureStats(),
+			LatencyStats:         consumer.GetLatencyStats(),
+			LcbExceptionStats:    consumer.GetLcbExceptionsStats(),
+		}
+	}
+
+	return workerStats
+}
+
 // PurgePlasmaRecords cleans up the plasma data store housing doc id timer related data
 // Given plasma records are for a specific app, we could simply purge the store from disk
 func (p *Producer) PurgePlasmaRecords() {
@@ -355,8 +388,53 @@ func (p *Producer) PurgePlasmaRecords() {
 
 // WriteAppLog dumps the application specific log message to configured file
 func (p *Producer) WriteAppLog(log string) {
-	ts := time.Now().Format("2006-01-02T15:04:05.000-07:00")
-	fmt.Fprintf(p.appLogWriter, "%s [INFO] %s\n", ts, log)
+	ts := time.Now().Format(util.AppLogTimestampFormat)
+	line := fmt.Sprintf("%s [INFO] %s", ts, log)
+	fmt.Fprintf(p.appLogWriter, "%s\n", line)
+
+	p.appLogSubsRWMutex.RLock()
+	defer p.appLogSubsRWMutex.RUnlock()
+
+	// Slow subscribers miss lines rather than stall the worker writing them
+	for _, ch := range p.appLogSubscribers {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// GetAppLog returns the last sz lines of application log
+func (p *Producer) GetAppLog(sz int) ([]string, error) {
+	wc, ok := p.appLogWriter.(*appLogCloser)
+	if !ok {
+		return nil, fmt.Errorf("App log not initialized")
+	}
+	return wc.tail(sz)
+}
+
+// SubscribeAppLog returns a channel receiving application log lines written after the
+// subscription, channel is closed when the producer stops
+func (p *Producer) SubscribeAppLog() (int64, <-chan string) {
+	p.appLogSubsRWMutex.Lock()
+	defer p.appLogSubsRWMutex.Unlock()
+
+	p.appLogSubscriberID++
+	ch := make(chan string, appLogSubscriberBufSize)
+	p.appLogSubscribers[p.appLogSubscriberID] = ch
+
+	return p.appLogSubscriberID, ch
+}
+
+// UnsubscribeAppLog stops delivery of application log lines to the subscriber
+func (p *Producer) UnsubscribeAppLog(id int64) {
+	p.appLogSubsRWMutex.Lock()
+	defer p.appLogSubsRWMutex.Unlock()
+
+	if ch, ok := p.appLogSubscribers[id]; ok {
+		close(ch)
+		delete(p.appLogSubscribers, id)
+	}
 }
 
 // GetPlasmaStats returns internal stats from plasma
@@ -376,6 +454,11 @@ func (p *Producer) GetPlasmaStats() (map[string]interface{}, error) {
 	return res, nil
 }
 
+// IsProcessingPaused reports whether processing of events has been paused for the app
+func (p *Producer) IsProcessingPaused() bool {
+	return p.handlerConfig.ProcessingPaused
+}
+
 // InternalVbDistributionStats returns internal state of vbucket ownership distribution on local eventing node
 func (p *Producer) InternalVbDistributionStats() map[string]string {
 	distributionStats := make(map[string]string)
@@ -5,25 +5,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"time"
 
-	cm "github.com/couchbase/eventing/common"
 	"github.com/couchbase/eventing/util"
 )
 
 // EventsProcessedPSec reports back aggregate of events processed/sec from all running consumers
 func (p *Producer) EventsProcessedPSec(w http.ResponseWriter, r *http.Request) {
-	var eventsProcessedPSec cm.EventProcessingStats
+	eventsProcessedPSec := p.GetEventsProcessedPSec()
 
-	for _, consumer := range p.runningConsumers {
-		pStats := consumer.EventsProcessedPSec()
-
-		eventsProcessedPSec.DcpEventsProcessedPSec += pStats.DcpEventsProcessedPSec
-		eventsProcessedPSec.TimerEventsProcessedPSec += pStats.TimerEventsProcessedPSec
-	}
-	eventsProcessedPSec.Timestamp = time.Now().Format("2006-01-02T15:04:05Z")
-
-	encodedStats, err := json.Marshal(&eventsProcessedPSec)
+	encodedStats, err := json.Marshal(eventsProcessedPSec)
 	if err != nil {
 		fmt.Fprintf(w, "Failed to encode event processing stats")
 		return
@@ -5,7 +5,9 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"strings"
 	"sync"
 )
 
@@ -190,6 +192,66 @@ func (wc *appLogCloser) Close() error {
 	return nil
 }
 
+// Returns the last sz complete lines of the app log, reading through rotated
+// <app_name>.<n>.gz files when the current file doesn't have enough of them
+func (wc *appLogCloser) tail(sz int) ([]string, error) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	if wc.closed {
+		return nil, fmt.Errorf("file handle is closed")
+	}
+
+	buf := make([]byte, wc.size)
+	_, err := wc.file.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	// Skip the line that is yet to be completely written
+	lines := splitAppLogLines(buf[:bytes.LastIndexByte(buf, '\n')+1])
+
+	for n := 1; len(lines) < sz; n++ {
+		buf, err = readRotatedAppLog(fmt.Sprintf("%s.%d.gz", wc.path, n))
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(splitAppLogLines(buf), lines...)
+	}
+
+	if len(lines) > sz {
+		lines = lines[len(lines)-sz:]
+	}
+	return lines, nil
+}
+
+func readRotatedAppLog(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	return ioutil.ReadAll(gr)
+}
+
+func splitAppLogLines(buf []byte) []string {
+	content := strings.TrimSuffix(string(buf), "\n")
+	if content == "" {
+		return []string{}
+	}
+	return strings.Split(content, "\n")
+}
+
 func openAppLog(path string, perm os.FileMode, maxSize int64, maxFiles int) (io.WriteCloser, error) {
 	if maxSize < 1 {
 		return nil, fmt.Errorf("maxSize should be > 1")
@@ -24,6 +24,8 @@ import (
 func NewProducer(appName, eventingPort, eventingSSLPort, eventingDir, kvPort, metakvAppHostPortsPath, nsServerPort, uuid, diagDir string,
 	memoryQuota int64, numVbuckets int, superSup common.EventingSuperSup) *Producer {
 	p := &Producer{
+		appLogSubscribers:      make(map[int64]chan string),
+		appLogSubsRWMutex:      &sync.RWMutex{},
 		appName:                appName,
 		bootstrapFinishCh:      make(chan struct{}, 1),
 		dcpConfig:              make(map[string]interface{}),
@@ -71,6 +73,9 @@ func (p *Producer) Serve() {
 		return
 	}
 
+	p.dcpEventRateLimiter = util.NewRateLimiter(p.handlerConfig.MaxEventsPerSec)
+	p.timerEventRateLimiter = util.NewRateLimiter(p.handlerConfig.MaxTimerEventsPerSec)
+
 	p.persistAllTicker = time.NewTicker(time.Duration(p.persistInterval) * time.Millisecond)
 	p.statsTicker = time.NewTicker(time.Duration(p.handlerConfig.StatsLogInterval) * time.Millisecond)
 	p.updateStatsTicker = time.NewTicker(time.Duration(p.handlerConfig.CheckpointInterval) * time.Millisecond)
@@ -200,6 +205,21 @@ func (p *Producer) Serve() {
 			logLevel := settings["log_level"].(string)
 			logging.SetLogLevel(util.GetLogLevel(logLevel))
 
+			// Consumers spawned later on, e.g. after a worker crash, must honour the latest pause state
+			if val, ok := settings["processing_paused"]; ok {
+				p.handlerConfig.ProcessingPaused = val.(bool)
+			}
+
+			if val, ok := settings["max_events_per_sec"]; ok {
+				p.handlerConfig.MaxEventsPerSec = int(val.(float64))
+				p.dcpEventRateLimiter.SetRate(p.handlerConfig.MaxEventsPerSec)
+			}
+
+			if val, ok := settings["max_timer_events_per_sec"]; ok {
+				p.handlerConfig.MaxTimerEventsPerSec = int(val.(float64))
+				p.timerEventRateLimiter.SetRate(p.handlerConfig.MaxTimerEventsPerSec)
+			}
+
 		case <-p.pauseProducerCh:
 
 			// This routine cleans up everything apart from metadataBucketHandle,
@@ -259,6 +279,7 @@ func (p *Producer) Stop() {
 	p.signalStopPersistAllCh <- struct{}{}
 
 	p.appLogWriter.Close()
+	p.closeAppLogSubscribers()
 
 	p.updateStatsStopCh <- struct{}{}
 }
@@ -344,7 +365,8 @@ func (p *Producer) handleV8Consumer(workerName string, vbnos []uint16, index int
 		len(vbnos), util.Condense(vbnos))
 
 	c := consumer.NewConsumer(p.handlerConfig, p.processConfig, p.rebalanceConfig, index, p.uuid,
-		p.eventingNodeUUIDs, vbnos, p.app, p.dcpConfig, p, p.superSup, p.vbPlasmaStore, p.iteratorRefreshCounter, p.numVbuckets)
+		p.eventingNodeUUIDs, vbnos, p.app, p.dcpConfig, p, p.superSup, p.vbPlasmaStore, p.iteratorRefreshCounter, p.numVbuckets,
+		p.dcpEventRateLimiter, p.timerEventRateLimiter)
 
 	p.Lock()
 	p.consumerListeners = append(p.consumerListeners, listener)
@@ -546,3 +568,13 @@ func (p *Producer) updateStats() {
 		}
 	}
 }
+
+func (p *Producer) closeAppLogSubscribers() {
+	p.appLogSubsRWMutex.Lock()
+	defer p.appLogSubsRWMutex.Unlock()
+
+	for id, ch := range p.appLogSubscribers {
+		close(ch)
+		delete(p.appLogSubscribers, id)
+	}
+}
@@ -0,0 +1,268 @@
+package servicemanager
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+func (m *ServiceMgr) parseAppLogLines(params url.Values) (sz int, info *runtimeInfo) {
+	info = &runtimeInfo{}
+	sz = defaultAppLogLines
+
+	if value := params.Get("lines"); value != "" {
+		var err error
+		sz, err = strconv.Atoi(value)
+		if err != nil || sz <= 0 || sz > maxAppLogLines {
+			info.Code = m.statusCodes.errInvalidConfig.Code
+			info.Info = fmt.Sprintf("lines must be a number between 1 and %d, got: %s", maxAppLogLines, value)
+			return
+		}
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+// Relays lines until the channel is closed or the client goes away
+func streamAppLog(w http.ResponseWriter, r *http.Request, lines <-chan string, write func(line string)) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	keepAliveTicker := time.NewTicker(appLogKeepAliveInterval)
+	defer keepAliveTicker.Stop()
+
+	flusher.Flush()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			write(line)
+			flusher.Flush()
+
+		case <-keepAliveTicker.C:
+			write("")
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Follows app log of every eventing node, lines are relayed in the order they arrive
+func (m *ServiceMgr) subscribeClusterAppLog(appName string, stopCh chan struct{}) <-chan string {
+	logPrefix := "ServiceMgr::subscribeClusterAppLog"
+
+	util.Retry(util.NewFixedBackoff(time.Second), getEventingNodesAddressesOpCallback, m)
+
+	linesCh := make(chan string, len(m.eventingNodeAddrs))
+	netClient := util.NewClient(0)
+
+	var wg sync.WaitGroup
+
+	for _, nodeAddr := range m.eventingNodeAddrs {
+		wg.Add(1)
+
+		go func(nodeAddr string) {
+			defer wg.Done()
+
+			endpointURL := fmt.Sprintf("http://%s/getAppLog?name=%s&follow=true", nodeAddr, url.QueryEscape(appName))
+
+			res, err := netClient.Get(endpointURL)
+			if err != nil {
+				logging.Errorf("%s Failed to follow app log from url: %rs, err: %v", logPrefix, endpointURL, err)
+				return
+			}
+			defer res.Body.Close()
+
+			go func() {
+				<-stopCh
+				res.Body.Close()
+			}()
+
+			scanner := bufio.NewScanner(res.Body)
+			scanner.Buffer(make([]byte, bufio.MaxScanTokenSize), maxAppLogLineSize)
+			for scanner.Scan() {
+				select {
+				case linesCh <- scanner.Text():
+				case <-stopCh:
+					return
+				}
+			}
+		}(nodeAddr)
+	}
+
+	go func() {
+		wg.Wait()
+		close(linesCh)
+	}()
+
+	return linesCh
+}
+
+func (m *ServiceMgr) appLogHandler(w http.ResponseWriter, r *http.Request, appName string) {
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.FetchDrafts, r, appName)
+
+	if _, info := m.getTempStore(appName); info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	params := r.URL.Query()
+	aggregate := params.Get("aggregate") == "true"
+
+	if params.Get("follow") == "true" {
+		m.followAppLog(w, r, appName, aggregate)
+		return
+	}
+
+	sz, info := m.parseAppLogLines(params)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	var lines []string
+	var err error
+
+	if aggregate {
+		util.Retry(util.NewFixedBackoff(time.Second), getEventingNodesAddressesOpCallback, m)
+
+		urlSuffix := fmt.Sprintf("/getAppLog?name=%s&lines=%d", url.QueryEscape(appName), sz)
+		lines, err = util.GetAppLog(urlSuffix, m.eventingNodeAddrs, sz)
+		if err != nil {
+			info.Code = m.statusCodes.errGetAppLog.Code
+			info.Info = fmt.Sprintf("Failed to get app log from all eventing nodes, err: %v", err)
+			m.sendErrorInfo(w, info)
+			return
+		}
+	} else {
+		lines, err = m.superSup.GetAppLog(appName, sz)
+		if err != nil {
+			info.Code = m.statusCodes.errAppNotDeployed.Code
+			info.Info = fmt.Sprintf("Function: %s app log isn't available on this node, err: %v", appName, err)
+			m.sendErrorInfo(w, info)
+			return
+		}
+	}
+
+	response, err := json.Marshal(lines)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal app log, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
+
+// Streams app log lines as server-sent events
+func (m *ServiceMgr) followAppLog(w http.ResponseWriter, r *http.Request, appName string, aggregate bool) {
+	var lines <-chan string
+
+	if aggregate {
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+
+		lines = m.subscribeClusterAppLog(appName, stopCh)
+	} else {
+		id, ch, err := m.superSup.SubscribeAppLog(appName)
+		if err != nil {
+			info := &runtimeInfo{}
+			info.Code = m.statusCodes.errAppNotDeployed.Code
+			info.Info = fmt.Sprintf("Function: %s app log isn't available on this node, err: %v", appName, err)
+			m.sendErrorInfo(w, info)
+			return
+		}
+		defer m.superSup.UnsubscribeAppLog(appName, id)
+
+		lines = ch
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+
+	streamAppLog(w, r, lines, func(line string) {
+		if line == "" {
+			fmt.Fprintf(w, ":\n\n")
+			return
+		}
+
+		for _, l := range strings.Split(line, "\n") {
+			fmt.Fprintf(w, "data: %s\n", l)
+		}
+		fmt.Fprintf(w, "\n")
+	})
+}
+
+// Serves app log written on this node, to be aggregated by the node handling the REST call
+func (m *ServiceMgr) getAppLog(w http.ResponseWriter, r *http.Request) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
+		return
+	}
+
+	params := r.URL.Query()
+	appName := params.Get("name")
+
+	if params.Get("follow") == "true" {
+		id, ch, err := m.superSup.SubscribeAppLog(appName)
+		if err != nil {
+			return
+		}
+		defer m.superSup.UnsubscribeAppLog(appName, id)
+
+		w.Header().Set("Content-Type", "text/plain")
+		streamAppLog(w, r, ch, func(line string) {
+			if line != "" {
+				fmt.Fprintf(w, "%s\n", line)
+			}
+		})
+		return
+	}
+
+	sz, info := m.parseAppLogLines(params)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	// Function might not be running on every eventing node
+	lines, err := m.superSup.GetAppLog(appName, sz)
+	if err != nil {
+		lines = []string{}
+	}
+
+	buf, err := json.Marshal(lines)
+	if err != nil {
+		logging.Errorf("Failed to marshal app log, err: %v", err)
+		return
+	}
+
+	fmt.Fprintf(w, "%s", string(buf))
+}
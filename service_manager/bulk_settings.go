@@ -0,0 +1,174 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/logging"
+)
+
+// Functions matching every non-empty field of the selector are picked
+type functionSelector struct {
+	Name         string `json:"name"` // glob, as in path.Match
+	SourceBucket string `json:"source_bucket"`
+	Label        string `json:"label"` // key:value, or just key
+}
+
+type bulkSettings struct {
+	Selector functionSelector       `json:"selector"`
+	Settings map[string]interface{} `json:"settings"`
+}
+
+type bulkSettingsResult struct {
+	Name string `json:"name"`
+	runtimeInfo
+}
+
+func (m *ServiceMgr) validateSelector(selector functionSelector) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+	info.Code = m.statusCodes.errInvalidConfig.Code
+
+	if selector.Name == "" && selector.SourceBucket == "" && selector.Label == "" {
+		info.Info = "Selector must have at least one of name, source_bucket or label"
+		return
+	}
+
+	if _, err := path.Match(selector.Name, ""); err != nil {
+		info.Info = fmt.Sprintf("Invalid name pattern: %s, err: %v", selector.Name, err)
+		return
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+func (selector functionSelector) matches(app application) bool {
+	if selector.Name != "" {
+		if matched, _ := path.Match(selector.Name, app.Name); !matched {
+			return false
+		}
+	}
+
+	if selector.SourceBucket != "" && selector.SourceBucket != app.DeploymentConfig.SourceBucket {
+		return false
+	}
+
+	if selector.Label != "" && !matchesLabel(app.Labels, selector.Label) {
+		return false
+	}
+
+	return true
+}
+
+// Settings are applied only if the patch validates against every selected function, though
+// failure to apply to one of them doesn't roll back those already applied
+func (m *ServiceMgr) bulkSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	logPrefix := "ServiceMgr::bulkSettingsHandler"
+
+	w.Header().Set("Content-Type", "application/json")
+	if !m.validateAuth(w, r, EventingPermissionManage) {
+		return
+	}
+
+	if r.Method != "POST" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	info := &runtimeInfo{}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		info.Code = m.statusCodes.errReadReq.Code
+		info.Info = fmt.Sprintf("Failed to read request body, err: %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	var req bulkSettings
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		info.Code = m.statusCodes.errUnmarshalPld.Code
+		info.Info = fmt.Sprintf("Failed to unmarshal payload err: %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	if info = m.validateSelector(req.Selector); info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	if len(req.Settings) == 0 {
+		info.Code = m.statusCodes.errInvalidConfig.Code
+		info.Info = "Settings to apply must not be empty"
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	patch, err := json.Marshal(req.Settings)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal settings as JSON, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	targets := make([]application, 0)
+	for _, app := range m.getTempStoreAll() {
+		if app.Name != "" && req.Selector.matches(app) {
+			targets = append(targets, app)
+		}
+	}
+
+	results := make([]bulkSettingsResult, 0, len(targets))
+	valid := true
+
+	for _, app := range targets {
+		settings := make(map[string]interface{})
+		for setting, value := range app.Settings {
+			settings[setting] = value
+		}
+		for setting, value := range req.Settings {
+			settings[setting] = value
+		}
+
+		info := m.validateSettings(settings)
+		valid = valid && info.Code == m.statusCodes.ok.Code
+		results = append(results, bulkSettingsResult{Name: app.Name, runtimeInfo: *info})
+	}
+
+	if valid {
+		for i, app := range targets {
+			audit.Log(auditevent.SettingsChanged, r, settingsChangeContext(app.Name, app.Settings, req.Settings))
+
+			results[i].runtimeInfo = *m.setSettings(app.Name, patch, m.getAppRevs(app.Name))
+
+			if results[i].Code != m.statusCodes.ok.Code {
+				logging.Errorf("%s Failed to apply settings to function: %s, err: %v", logPrefix, app.Name, results[i].Info)
+			}
+		}
+	}
+
+	response, err := json.Marshal(results)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal response, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	if !valid {
+		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errInvalidConfig.Code))
+		w.WriteHeader(m.getDisposition(m.statusCodes.errInvalidConfig.Code))
+	} else {
+		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	}
+	fmt.Fprintf(w, "%s", string(response))
+}
@@ -2,6 +2,7 @@ package servicemanager
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/couchbase/cbauth/service"
@@ -10,26 +11,44 @@ import (
 )
 
 const (
-	metakvEventingPath       = "/eventing/"
-	metakvAppsPath           = metakvEventingPath + "apps/"
-	metakvAppSettingsPath    = metakvEventingPath + "appsettings/"     // function settings
-	metakvConfigKeepNodes    = metakvEventingPath + "config/keepNodes" // Store list of eventing keepNodes
-	metakvConfigPath         = metakvEventingPath + "config/settings"  // global settings
-	metakvRebalanceTokenPath = metakvEventingPath + "rebalanceToken/"
-	metakvRebalanceProgress  = metakvEventingPath + "rebalanceProgress/"
-	metakvTempAppsPath       = metakvEventingPath + "tempApps/"
-	metakvChecksumPath       = metakvEventingPath + "checksum/"
-	metakvTempChecksumPath   = metakvEventingPath + "tempchecksum/"
-	stopRebalance            = "stopRebalance"
+	metakvEventingPath         = "/eventing/"
+	metakvAppsPath             = metakvEventingPath + "apps/"
+	metakvAppSettingsPath      = metakvEventingPath + "appsettings/"     // function settings
+	metakvAppLabelsPath        = metakvEventingPath + "applabels/"       // function labels
+	metakvAppRevisionsPath     = metakvEventingPath + "appRevisions/"    // function revision history
+	metakvConfigKeepNodes      = metakvEventingPath + "config/keepNodes" // Store list of eventing keepNodes
+	metakvConfigDir            = metakvEventingPath + "config/"          // watched for changes to global settings
+	metakvConfigPath           = metakvEventingPath + "config/settings"  // global settings
+	metakvRebalanceTokenPath   = metakvEventingPath + "rebalanceToken/"
+	metakvRebalanceProgress    = metakvEventingPath + "rebalanceProgress/"
+	metakvTempAppsPath         = metakvEventingPath + "tempApps/"
+	metakvChecksumPath         = metakvEventingPath + "checksum/"
+	metakvTempChecksumPath     = metakvEventingPath + "tempchecksum/"
+	metakvRevisionChecksumPath = metakvEventingPath + "revisionchecksum/"
+	stopRebalance              = "stopRebalance"
 )
 
 const (
 	rebalanceProgressUpdateTickInterval = time.Duration(3000) * time.Millisecond
+
+	// Comment line sent on idle app log streams, so that proxies don't drop them
+	appLogKeepAliveInterval = time.Duration(15000) * time.Millisecond
+
+	// Event processing stats of deployed functions are sampled at this interval, with
+	// statsHistorySize samples retained per function i.e. last 1hr of history
+	statsHistoryInterval = time.Duration(10000) * time.Millisecond
+	statsHistorySize     = 360
 )
 
 const (
 	// EventingPermissionManage for auditing
 	EventingPermissionManage = "cluster.eventing.functions!manage"
+
+	// EventingPermissionRead allows reading function definitions, settings, config and logs
+	EventingPermissionRead = "cluster.settings!read"
+
+	// EventingPermissionStats allows reading stats of functions
+	EventingPermissionStats = "cluster.stats!read"
 )
 
 const (
@@ -50,6 +69,21 @@ const (
 
 const (
 	maxHandlerSize = 128 * 1024
+
+	// Number of revisions of a function retained in metakv
+	maxAppRevisions = 50
+
+	defaultAppLogLines = 100
+	maxAppLogLines     = 10000
+
+	// Longest app log line relayed when following app log of other eventing nodes
+	maxAppLogLineSize = 1024 * 1024
+
+	maxLabels      = 32
+	maxLabelLength = 64
+
+	// Function with more DCP events than this remaining to be processed is reported unhealthy
+	defaultHealthBacklogThreshold = 1000000
 )
 
 // ServiceMgr implements cbauth_service interface
@@ -85,6 +119,10 @@ type ServiceMgr struct {
 	statusCodes   statusCodes
 	statusPayload []byte
 	errorCodes    map[int]errorPayload
+	errFormat     atomic.Value // error_format from global config, kept up to date by watchConfig
+
+	statsHistory        map[string]*statsRing // Access controlled by statsHistoryRWMutex
+	statsHistoryRWMutex *sync.RWMutex
 }
 
 type doneCallback func(err error, cancel <-chan struct{})
@@ -132,6 +170,7 @@ type application struct {
 	DeploymentConfig depCfg                 `json:"depcfg"`
 	AppHandlers      string                 `json:"appcode"`
 	Settings         map[string]interface{} `json:"settings"`
+	Labels           map[string]string      `json:"labels,omitempty"`
 }
 
 type depCfg struct {
@@ -157,6 +196,7 @@ type stats struct {
 	FailureStats                    interface{} `json:"failure_stats,omitempty"`
 	FunctionName                    interface{} `json:"function_name"`
 	InternalVbDistributionStats     interface{} `json:"internal_vb_distribution_stats,omitempty"`
+	Labels                          interface{} `json:"labels,omitempty"`
 	LatencyStats                    interface{} `json:"latency_stats,omitempty"`
 	LcbExceptionStats               interface{} `json:"lcb_exception_stats,omitempty"`
 	PlannerStats                    interface{} `json:"planner_stats,omitempty"`
@@ -170,6 +210,7 @@ type stats struct {
 type config struct {
 	RAMQuota       int    `json:"ram_quota"`
 	MetadataBucket string `json:"metadata_bucket"`
+	ErrorFormat    string `json:"error_format,omitempty"`
 }
 
 type configResponse struct {
@@ -0,0 +1,256 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+const (
+	writeViaBucketAlias = "bucket_alias"
+	writeViaN1QL        = "n1ql"
+)
+
+type bucketWrite struct {
+	Bucket string `json:"bucket"`
+	Via    string `json:"via"`
+	Alias  string `json:"alias,omitempty"`
+}
+
+type graphNode struct {
+	Name         string        `json:"name"`
+	SourceBucket string        `json:"source_bucket"`
+	Deployed     bool          `json:"deployed"`
+	Writes       []bucketWrite `json:"writes"`
+}
+
+// Edge from a function to another function whose source bucket it mutates
+type graphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	bucketWrite
+}
+
+type dependencyGraph struct {
+	Nodes  []graphNode `json:"nodes"`
+	Edges  []graphEdge `json:"edges"`
+	Cycles [][]string  `json:"cycles"`
+}
+
+type applicationsByName []application
+
+func (a applicationsByName) Len() int           { return len(a) }
+func (a applicationsByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+func (a applicationsByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// Buckets a function could mutate, through bucket aliases and N1QL DML statements
+func bucketWrites(app application) []bucketWrite {
+	writes := make([]bucketWrite, 0)
+
+	for _, b := range app.DeploymentConfig.Buckets {
+		writes = append(writes, bucketWrite{Bucket: b.BucketName, Via: writeViaBucketAlias, Alias: b.Alias})
+	}
+
+	keyspaces := make(map[string]struct{})
+	for _, stmt := range util.ExtractN1QLStatements(app.AppHandlers) {
+		parseInfo, _ := util.Parse(stmt.Query)
+		if !parseInfo.IsValid || !parseInfo.IsDmlQuery || parseInfo.KeyspaceName == "" {
+			continue
+		}
+
+		if _, ok := keyspaces[parseInfo.KeyspaceName]; !ok {
+			keyspaces[parseInfo.KeyspaceName] = struct{}{}
+			writes = append(writes, bucketWrite{Bucket: parseInfo.KeyspaceName, Via: writeViaN1QL})
+		}
+	}
+
+	return writes
+}
+
+func buildDependencyGraph(apps []application) *dependencyGraph {
+	graph := &dependencyGraph{
+		Nodes:  make([]graphNode, 0),
+		Edges:  make([]graphEdge, 0),
+		Cycles: make([][]string, 0),
+	}
+
+	sort.Sort(applicationsByName(apps))
+
+	sourceBuckets := make(map[string][]string)
+	for _, app := range apps {
+		deployed, _ := app.Settings["deployment_status"].(bool)
+
+		graph.Nodes = append(graph.Nodes, graphNode{
+			Name:         app.Name,
+			SourceBucket: app.DeploymentConfig.SourceBucket,
+			Deployed:     deployed,
+			Writes:       bucketWrites(app),
+		})
+
+		sourceBuckets[app.DeploymentConfig.SourceBucket] = append(sourceBuckets[app.DeploymentConfig.SourceBucket], app.Name)
+	}
+
+	adjacency := make(map[string][]string)
+	for _, node := range graph.Nodes {
+		for _, write := range node.Writes {
+			for _, to := range sourceBuckets[write.Bucket] {
+				graph.Edges = append(graph.Edges, graphEdge{From: node.Name, To: to, bucketWrite: write})
+				adjacency[node.Name] = append(adjacency[node.Name], to)
+			}
+		}
+	}
+
+	for _, component := range stronglyConnectedComponents(graph.Nodes, adjacency) {
+		if len(component) > 1 || hasSelfEdge(adjacency, component[0]) {
+			graph.Cycles = append(graph.Cycles, component)
+		}
+	}
+
+	return graph
+}
+
+func hasSelfEdge(adjacency map[string][]string, name string) bool {
+	for _, to := range adjacency[name] {
+		if to == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Tarjan's algorithm, every function taking part in a cycle ends up in the same component
+func stronglyConnectedComponents(nodes []graphNode, adjacency map[string][]string) [][]string {
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0)
+	components := make([][]string, 0)
+
+	var strongConnect func(name string)
+	strongConnect = func(name string) {
+		index[name] = len(index)
+		lowLink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, to := range adjacency[name] {
+			if _, visited := index[to]; !visited {
+				strongConnect(to)
+				if lowLink[to] < lowLink[name] {
+					lowLink[name] = lowLink[to]
+				}
+			} else if onStack[to] && index[to] < lowLink[name] {
+				lowLink[name] = index[to]
+			}
+		}
+
+		if lowLink[name] != index[name] {
+			return
+		}
+
+		component := make([]string, 0)
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+
+	for _, node := range nodes {
+		if _, visited := index[node.Name]; !visited {
+			strongConnect(node.Name)
+		}
+	}
+
+	return components
+}
+
+// Rejects deploying the app if it would form a cycle with deployed functions, each mutating the
+// source bucket of the next one. Function mutating its own source bucket is only warned about.
+func (m *ServiceMgr) checkMutationCycles(app application) (info *runtimeInfo) {
+	logPrefix := "ServiceMgr::checkMutationCycles"
+
+	info = &runtimeInfo{}
+
+	apps := []application{app}
+	for _, a := range m.getTempStoreAll() {
+		if a.Name == "" || a.Name == app.Name {
+			continue
+		}
+
+		if deployed, _ := a.Settings["deployment_status"].(bool); deployed {
+			apps = append(apps, a)
+		}
+	}
+
+	for _, cycle := range buildDependencyGraph(apps).Cycles {
+		member := false
+		for _, name := range cycle {
+			member = member || name == app.Name
+		}
+
+		if !member {
+			continue
+		}
+
+		if len(cycle) == 1 {
+			logging.Warnf("%s Function: %s mutates its own source bucket: %s", logPrefix, app.Name, app.DeploymentConfig.SourceBucket)
+			continue
+		}
+
+		info.Code = m.statusCodes.errMutationCycle.Code
+		info.Info = fmt.Sprintf("Function: %s would form a cycle of functions mutating each other's source bucket: %s",
+			app.Name, strings.Join(cycle, ", "))
+		return
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+func (m *ServiceMgr) graphHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !m.validateAuth(w, r, EventingPermissionRead) {
+		return
+	}
+
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.FetchDrafts, r, nil)
+
+	apps := make([]application, 0)
+	for _, app := range m.getTempStoreAll() {
+		if app.Name != "" {
+			apps = append(apps, app)
+		}
+	}
+
+	response, err := json.Marshal(buildDependencyGraph(apps))
+	if err != nil {
+		info := &runtimeInfo{}
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal dependency graph, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
@@ -0,0 +1,103 @@
+package servicemanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	tests := []struct {
+		name      string
+		nodes     []string
+		adjacency map[string][]string
+		want      [][]string
+	}{
+		{
+			name:  "no edges",
+			nodes: []string{"a", "b"},
+			want:  [][]string{{"a"}, {"b"}},
+		},
+		{
+			name:      "chain",
+			nodes:     []string{"a", "b", "c"},
+			adjacency: map[string][]string{"a": {"b"}, "b": {"c"}},
+			want:      [][]string{{"c"}, {"b"}, {"a"}},
+		},
+		{
+			name:      "self edge",
+			nodes:     []string{"a"},
+			adjacency: map[string][]string{"a": {"a"}},
+			want:      [][]string{{"a"}},
+		},
+		{
+			name:      "cycle",
+			nodes:     []string{"a", "b", "c"},
+			adjacency: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			want:      [][]string{{"a", "b", "c"}},
+		},
+		{
+			name:  "cycles joined by an edge",
+			nodes: []string{"a", "b", "c", "d", "e"},
+			adjacency: map[string][]string{
+				"a": {"b"}, "b": {"a", "c"},
+				"c": {"d"}, "d": {"c"},
+				"e": {"a"},
+			},
+			want: [][]string{{"c", "d"}, {"a", "b"}, {"e"}},
+		},
+	}
+
+	for _, test := range tests {
+		nodes := make([]graphNode, 0)
+		for _, name := range test.nodes {
+			nodes = append(nodes, graphNode{Name: name})
+		}
+
+		if got := stronglyConnectedComponents(nodes, test.adjacency); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBuildDependencyGraphCycles(t *testing.T) {
+	app := func(name, source string, aliased ...string) application {
+		a := application{Name: name, DeploymentConfig: depCfg{SourceBucket: source}}
+		for _, b := range aliased {
+			a.DeploymentConfig.Buckets = append(a.DeploymentConfig.Buckets, bucket{Alias: "dst", BucketName: b})
+		}
+		return a
+	}
+
+	tests := []struct {
+		name string
+		apps []application
+		want [][]string
+	}{
+		{
+			name: "no writes",
+			apps: []application{app("f1", "src"), app("f2", "src")},
+			want: [][]string{},
+		},
+		{
+			name: "chain",
+			apps: []application{app("f1", "a", "b"), app("f2", "b", "c")},
+			want: [][]string{},
+		},
+		{
+			name: "mutates own source bucket",
+			apps: []application{app("f1", "a", "a")},
+			want: [][]string{{"f1"}},
+		},
+		{
+			name: "functions mutating each other's source bucket",
+			apps: []application{app("f2", "b", "a"), app("f1", "a", "b"), app("f3", "c")},
+			want: [][]string{{"f1", "f2"}},
+		},
+	}
+
+	for _, test := range tests {
+		if got := buildDependencyGraph(test.apps).Cycles; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/consumer"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+const (
+	validationStageConfig      = "config"
+	validationStageBuckets     = "buckets"
+	validationStageCompilation = "compilation"
+	validationStageN1QL        = "n1ql"
+	validationStageCycles      = "cycles"
+)
+
+type validationProblem struct {
+	Stage string      `json:"stage"`
+	Code  int         `json:"code"`
+	Name  string      `json:"name"`
+	Info  interface{} `json:"info"`
+}
+
+type n1qlProblem struct {
+	Query string `json:"query"`
+	Line  int    `json:"line_number"`
+	Info  string `json:"info"`
+}
+
+type validationReport struct {
+	Name     string              `json:"name"`
+	Valid    bool                `json:"valid"`
+	Problems []validationProblem `json:"problems"`
+}
+
+func (report *validationReport) add(m *ServiceMgr, stage string, code int, info interface{}) {
+	report.Problems = append(report.Problems, validationProblem{
+		Stage: stage,
+		Code:  code,
+		Name:  m.errorCodes[code].Name,
+		Info:  info,
+	})
+}
+
+func (report *validationReport) addInfo(m *ServiceMgr, stage string, info *runtimeInfo) {
+	if info.Code != m.statusCodes.ok.Code {
+		report.add(m, stage, info.Code, info.Info)
+	}
+}
+
+// Runs every pre-deployment check against the app without storing anything. Unlike
+// validateApplication, checks carry on past a failure so that all problems are reported.
+func (m *ServiceMgr) validateForDeploy(app application) (report validationReport) {
+	logPrefix := "ServiceMgr::validateForDeploy"
+
+	report.Name = app.Name
+	report.Problems = make([]validationProblem, 0)
+
+	if app.Settings == nil {
+		app.Settings = make(map[string]interface{})
+	}
+
+	report.addInfo(m, validationStageConfig, m.validateApplicationName(app.Name))
+	report.addInfo(m, validationStageConfig, m.validateDeploymentConfig(&app.DeploymentConfig))
+	report.addInfo(m, validationStageConfig, m.validateNonEmpty(app.AppHandlers, "Function handler"))
+	report.addInfo(m, validationStageConfig, m.validateSettings(app.Settings))
+
+	if app.DeploymentConfig.SourceBucket == app.DeploymentConfig.MetadataBucket {
+		report.add(m, validationStageConfig, m.statusCodes.errSrcMbSame.Code,
+			fmt.Sprintf("Source bucket same as metadata bucket. source_bucket : %s metadata_bucket : %s",
+				app.DeploymentConfig.SourceBucket, app.DeploymentConfig.MetadataBucket))
+	}
+
+	report.addInfo(m, validationStageBuckets, m.validateBuckets(&app.DeploymentConfig))
+
+	appContent := m.encodeAppContent(app)
+	if len(appContent) > maxHandlerSize {
+		report.add(m, validationStageCompilation, m.statusCodes.errAppCodeSize.Code,
+			fmt.Sprintf("App: %s Handler Code size is more than 128K", app.Name))
+	}
+
+	if app.AppHandlers != "" {
+		c := &consumer.Consumer{}
+		compilationInfo, err := c.SpawnCompilationWorker(app.AppHandlers, string(appContent), app.Name, m.adminHTTPPort)
+		if err != nil {
+			report.add(m, validationStageCompilation, m.statusCodes.errHandlerCompile.Code,
+				fmt.Sprintf("Failed to compile handler, err: %v", err))
+		} else if !compilationInfo.CompileSuccess {
+			report.add(m, validationStageCompilation, m.statusCodes.errHandlerCompile.Code, compilationInfo)
+		}
+	}
+
+	for _, stmt := range util.ExtractN1QLStatements(app.AppHandlers) {
+		parseInfo, _ := util.Parse(stmt.Query)
+		if !parseInfo.IsValid {
+			report.add(m, validationStageN1QL, m.statusCodes.errInvalidN1QL.Code,
+				n1qlProblem{Query: stmt.Query, Line: stmt.Line, Info: parseInfo.Info})
+			continue
+		}
+
+		// Same restriction as the transpiler, DML can't mutate the source bucket
+		if parseInfo.IsDmlQuery && parseInfo.KeyspaceName == app.DeploymentConfig.SourceBucket {
+			report.add(m, validationStageN1QL, m.statusCodes.errInvalidN1QL.Code,
+				n1qlProblem{Query: stmt.Query, Line: stmt.Line,
+					Info: fmt.Sprintf("Can not execute DML query on bucket \"%s\"", app.DeploymentConfig.SourceBucket)})
+		}
+	}
+
+	report.addInfo(m, validationStageCycles, m.checkMutationCycles(app))
+
+	report.Valid = len(report.Problems) == 0
+
+	logging.Infof("%s Function: %s valid: %v problems: %d", logPrefix, app.Name, report.Valid, len(report.Problems))
+	return
+}
+
+func (m *ServiceMgr) validateHandler(w http.ResponseWriter, r *http.Request, appName string) {
+	if r.Method != "POST" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.FetchDrafts, r, appName)
+
+	info := &runtimeInfo{}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		info.Code = m.statusCodes.errReadReq.Code
+		info.Info = fmt.Sprintf("Failed to read request body, err: %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	// Stored draft is validated when no function definition is supplied
+	var app application
+	if len(data) == 0 {
+		if app, info = m.getTempStore(appName); info.Code != m.statusCodes.ok.Code {
+			m.sendErrorInfo(w, info)
+			return
+		}
+	} else {
+		err = json.Unmarshal(data, &app)
+		if err != nil {
+			info.Code = m.statusCodes.errUnmarshalPld.Code
+			info.Info = fmt.Sprintf("Failed to unmarshal payload err: %v", err)
+			m.sendErrorInfo(w, info)
+			return
+		}
+
+		if app.Name != appName {
+			info.Code = m.statusCodes.errAppNameMismatch.Code
+			info.Info = fmt.Sprintf("Function name in the URL (%s) and body (%s) must be same", appName, app.Name)
+			info.Field = "appname"
+			m.sendErrorInfo(w, info)
+			return
+		}
+	}
+
+	report := m.validateForDeploy(app)
+
+	response, err := json.Marshal(report)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal validation report, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	if report.Valid {
+		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	} else {
+		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppValidation.Code))
+		w.WriteHeader(m.getDisposition(m.statusCodes.errAppValidation.Code))
+	}
+
+	fmt.Fprintf(w, "%s", string(response))
+}
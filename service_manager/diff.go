@@ -0,0 +1,317 @@
+package servicemanager
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	diffEqual  = ' '
+	diffDelete = '-'
+	diffInsert = '+'
+
+	diffContextLines = 3
+
+	// Beyond these many edits, handler code is reported as fully replaced
+	// instead of computing a minimal diff
+	maxDiffEdits = 2000
+)
+
+type diffOp struct {
+	kind byte
+	text string
+	aPos int // Number of lines of "from" preceding this op
+	bPos int // Number of lines of "to" preceding this op
+}
+
+type fieldDiff struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type appDiff struct {
+	AppCodeDiff  string               `json:"appcode_diff"`
+	DepCfgDiff   map[string]fieldDiff `json:"depcfg_diff"`
+	SettingsDiff map[string]fieldDiff `json:"settings_diff"`
+	LabelsDiff   map[string]fieldDiff `json:"labels_diff"`
+	Changed      bool                 `json:"changed"`
+}
+
+func diffApplications(from, to application, fromLabel, toLabel string) appDiff {
+	diff := appDiff{
+		DepCfgDiff:   diffDepCfg(from.DeploymentConfig, to.DeploymentConfig),
+		SettingsDiff: diffSettings(from.Settings, to.Settings),
+		LabelsDiff:   diffLabels(from.Labels, to.Labels),
+	}
+
+	if from.AppHandlers != to.AppHandlers {
+		diff.AppCodeDiff = unifiedDiff(fromLabel, toLabel, from.AppHandlers, to.AppHandlers)
+	}
+
+	diff.Changed = diff.AppCodeDiff != "" || len(diff.DepCfgDiff) > 0 || len(diff.SettingsDiff) > 0 || len(diff.LabelsDiff) > 0
+	return diff
+}
+
+// Same outcome as Changed of diffApplications, without computing a diff of handler code
+func sameApplication(a, b application) bool {
+	return a.AppHandlers == b.AppHandlers &&
+		len(diffDepCfg(a.DeploymentConfig, b.DeploymentConfig)) == 0 &&
+		len(diffSettings(a.Settings, b.Settings)) == 0 &&
+		len(diffLabels(a.Labels, b.Labels)) == 0
+}
+
+func diffDepCfg(from, to depCfg) map[string]fieldDiff {
+	diff := make(map[string]fieldDiff)
+
+	if from.SourceBucket != to.SourceBucket {
+		diff["source_bucket"] = fieldDiff{from.SourceBucket, to.SourceBucket}
+	}
+
+	if from.MetadataBucket != to.MetadataBucket {
+		diff["metadata_bucket"] = fieldDiff{from.MetadataBucket, to.MetadataBucket}
+	}
+
+	fromAliases := make(map[string]string)
+	for _, b := range from.Buckets {
+		fromAliases[b.Alias] = b.BucketName
+	}
+
+	toAliases := make(map[string]string)
+	for _, b := range to.Buckets {
+		toAliases[b.Alias] = b.BucketName
+	}
+
+	for alias, bucketName := range fromAliases {
+		if toBucketName, ok := toAliases[alias]; !ok {
+			diff["buckets."+alias] = fieldDiff{bucketName, nil}
+		} else if toBucketName != bucketName {
+			diff["buckets."+alias] = fieldDiff{bucketName, toBucketName}
+		}
+	}
+
+	for alias, bucketName := range toAliases {
+		if _, ok := fromAliases[alias]; !ok {
+			diff["buckets."+alias] = fieldDiff{nil, bucketName}
+		}
+	}
+
+	return diff
+}
+
+func diffSettings(from, to map[string]interface{}) map[string]fieldDiff {
+	diff := make(map[string]fieldDiff)
+
+	for key, val := range from {
+		if toVal, ok := to[key]; !ok {
+			diff[key] = fieldDiff{val, nil}
+		} else if !reflect.DeepEqual(val, toVal) {
+			diff[key] = fieldDiff{val, toVal}
+		}
+	}
+
+	for key, val := range to {
+		if _, ok := from[key]; !ok {
+			diff[key] = fieldDiff{nil, val}
+		}
+	}
+
+	return diff
+}
+
+func diffLabels(from, to map[string]string) map[string]fieldDiff {
+	diff := make(map[string]fieldDiff)
+
+	for key, val := range from {
+		if toVal, ok := to[key]; !ok {
+			diff[key] = fieldDiff{val, nil}
+		} else if val != toVal {
+			diff[key] = fieldDiff{val, toVal}
+		}
+	}
+
+	for key, val := range to {
+		if _, ok := from[key]; !ok {
+			diff[key] = fieldDiff{nil, val}
+		}
+	}
+
+	return diff
+}
+
+// Returns a unified diff, with 3 lines of context, between two blobs of handler code
+func unifiedDiff(fromLabel, toLabel, from, to string) string {
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromLabel, toLabel)
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == diffEqual {
+			i++
+			continue
+		}
+
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+
+		// Changes separated by less than 2*diffContextLines equal lines share a hunk
+		last := i
+		for j := i + 1; j < len(ops); j++ {
+			if ops[j].kind != diffEqual {
+				last = j
+			} else if j-last > 2*diffContextLines {
+				break
+			}
+		}
+
+		stop := last + 1 + diffContextLines
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		writeHunk(&buf, ops[start:stop])
+		i = stop
+	}
+
+	return buf.String()
+}
+
+func writeHunk(buf *bytes.Buffer, ops []diffOp) {
+	var aCount, bCount int
+	for _, op := range ops {
+		if op.kind != diffInsert {
+			aCount++
+		}
+		if op.kind != diffDelete {
+			bCount++
+		}
+	}
+
+	aStart, bStart := ops[0].aPos, ops[0].bPos
+	if aCount > 0 {
+		aStart++
+	}
+	if bCount > 0 {
+		bStart++
+	}
+
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+	for _, op := range ops {
+		fmt.Fprintf(buf, "%c%s\n", op.kind, op.text)
+	}
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Computes line level edit script using Myers' O(ND) algorithm
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+
+	maxEdits := n + m
+	if maxEdits > maxDiffEdits {
+		maxEdits = maxDiffEdits
+	}
+
+	offset := maxEdits + 1
+	v := make([]int, 2*maxEdits+3)
+
+	// trace[d] holds furthest reaching x per diagonal k in [-d-1, d+1] before step d
+	var trace [][]int
+	found := false
+
+	for d := 0; d <= maxEdits && !found; d++ {
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		return replaceAll(a, b)
+	}
+
+	var ops []diffOp
+	x, y := n, m
+
+	for d := len(trace) - 1; d > 0; d-- {
+		snapshot := trace[d]
+		get := func(k int) int { return snapshot[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := get(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{kind: diffEqual, text: a[x-1], aPos: x - 1, bPos: y - 1})
+			x--
+			y--
+		}
+
+		if x == prevX {
+			ops = append(ops, diffOp{kind: diffInsert, text: b[y-1], aPos: x, bPos: y - 1})
+			y--
+		} else {
+			ops = append(ops, diffOp{kind: diffDelete, text: a[x-1], aPos: x - 1, bPos: y})
+			x--
+		}
+	}
+
+	for x > 0 && y > 0 {
+		ops = append(ops, diffOp{kind: diffEqual, text: a[x-1], aPos: x - 1, bPos: y - 1})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops
+}
+
+func replaceAll(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i, line := range a {
+		ops = append(ops, diffOp{kind: diffDelete, text: line, aPos: i, bPos: 0})
+	}
+	for i, line := range b {
+		ops = append(ops, diffOp{kind: diffInsert, text: line, aPos: len(a), bPos: i})
+	}
+	return ops
+}
@@ -0,0 +1,226 @@
+package servicemanager
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Length of longest common subsequence, which a minimal edit script keeps as equal lines
+func lcsLength(a, b []string) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] > lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	return lengths[0][0]
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"both empty", "", ""},
+		{"from empty", "", "a\nb\n"},
+		{"to empty", "a\nb\n", ""},
+		{"equal", "a\nb\nc\n", "a\nb\nc\n"},
+		{"line changed", "a\nb\nc\n", "a\nB\nc\n"},
+		{"line inserted", "a\nc\n", "a\nb\nc\n"},
+		{"line deleted", "a\nb\nc\n", "a\nc\n"},
+		{"lines moved", "a\nb\nc\nd\n", "c\nd\na\nb\n"},
+		{"repeated lines", "a\nb\na\nb\na\n", "b\na\nb\na\nb\n"},
+		{"classic", "a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n"},
+	}
+
+	for _, test := range tests {
+		a, b := splitLines(test.a), splitLines(test.b)
+		ops := diffLines(a, b)
+
+		var from, to []string
+		edits := 0
+		for _, op := range ops {
+			switch op.kind {
+			case diffEqual:
+				from = append(from, op.text)
+				to = append(to, op.text)
+			case diffDelete:
+				from = append(from, op.text)
+				edits++
+			case diffInsert:
+				to = append(to, op.text)
+				edits++
+			}
+		}
+
+		if strings.Join(from, "\n") != strings.Join(a, "\n") {
+			t.Errorf("%s: ops don't reproduce from, got %q want %q", test.name, from, a)
+		}
+
+		if strings.Join(to, "\n") != strings.Join(b, "\n") {
+			t.Errorf("%s: ops don't reproduce to, got %q want %q", test.name, to, b)
+		}
+
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Errorf("%s: got %d edits, minimal is %d", test.name, edits, want)
+		}
+	}
+}
+
+func TestDiffLinesBeyondMaxEdits(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxDiffEdits; i++ {
+		a = append(a, fmt.Sprintf("from %d", i))
+		b = append(b, fmt.Sprintf("to %d", i))
+	}
+
+	ops := diffLines(a, b)
+	if len(ops) != len(a)+len(b) {
+		t.Fatalf("got %d ops, want %d", len(ops), len(a)+len(b))
+	}
+
+	for i, op := range ops {
+		if i < len(a) && op.kind != diffDelete || i >= len(a) && op.kind != diffInsert {
+			t.Fatalf("op %d is %c, want every line of from deleted before every line of to is inserted", i, op.kind)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{
+			name: "equal",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+			want: "--- from\n+++ to\n",
+		},
+		{
+			name: "line changed",
+			from: "x\ny\nz\n",
+			to:   "x\nY\nz\n",
+			want: "--- from\n+++ to\n@@ -1,3 +1,3 @@\n x\n-y\n+Y\n z\n",
+		},
+		{
+			name: "from empty",
+			from: "",
+			to:   "a\n",
+			want: "--- from\n+++ to\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name: "changes far apart get separate hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			to:   "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "--- from\n+++ to\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+	}
+
+	for _, test := range tests {
+		if got := unifiedDiff("from", "to", test.from, test.to); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDiffApplications(t *testing.T) {
+	base := func() application {
+		return application{
+			Name:             "app",
+			AppHandlers:      "function OnUpdate(doc, meta) {}\n",
+			DeploymentConfig: depCfg{SourceBucket: "src", MetadataBucket: "meta"},
+			Settings:         map[string]interface{}{"worker_count": float64(3)},
+			Labels:           map[string]string{"team": "orders"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(app *application)
+		want   appDiff
+	}{
+		{
+			name:   "unchanged",
+			change: func(app *application) {},
+			want:   appDiff{},
+		},
+		{
+			name:   "handler changed",
+			change: func(app *application) { app.AppHandlers = "function OnDelete(meta) {}\n" },
+			want: appDiff{
+				AppCodeDiff: "--- from\n+++ to\n@@ -1,1 +1,1 @@\n-function OnUpdate(doc, meta) {}\n+function OnDelete(meta) {}\n",
+				Changed:     true,
+			},
+		},
+		{
+			name:   "depcfg changed",
+			change: func(app *application) { app.DeploymentConfig.Buckets = []bucket{{Alias: "dst", BucketName: "dst"}} },
+			want: appDiff{
+				DepCfgDiff: map[string]fieldDiff{"buckets.dst": {nil, "dst"}},
+				Changed:    true,
+			},
+		},
+		{
+			name:   "setting changed",
+			change: func(app *application) { app.Settings["worker_count"] = float64(4) },
+			want: appDiff{
+				SettingsDiff: map[string]fieldDiff{"worker_count": {float64(3), float64(4)}},
+				Changed:      true,
+			},
+		},
+		{
+			name:   "labels changed",
+			change: func(app *application) { app.Labels = map[string]string{"team": "billing", "tier": "1"} },
+			want: appDiff{
+				LabelsDiff: map[string]fieldDiff{"team": {"orders", "billing"}, "tier": {nil, "1"}},
+				Changed:    true,
+			},
+		},
+		{
+			name:   "labels removed",
+			change: func(app *application) { app.Labels = nil },
+			want: appDiff{
+				LabelsDiff: map[string]fieldDiff{"team": {"orders", nil}},
+				Changed:    true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		from, to := base(), base()
+		test.change(&to)
+
+		got := diffApplications(from, to, "from", "to")
+		if got.AppCodeDiff != test.want.AppCodeDiff || got.Changed != test.want.Changed ||
+			!sameFieldDiffs(got.DepCfgDiff, test.want.DepCfgDiff) ||
+			!sameFieldDiffs(got.SettingsDiff, test.want.SettingsDiff) ||
+			!sameFieldDiffs(got.LabelsDiff, test.want.LabelsDiff) {
+			t.Errorf("%s: got %+v want %+v", test.name, got, test.want)
+		}
+
+		if same := sameApplication(from, to); same == test.want.Changed {
+			t.Errorf("%s: sameApplication got %v, diff changed %v", test.name, same, test.want.Changed)
+		}
+	}
+}
+
+func sameFieldDiffs(a, b map[string]fieldDiff) bool {
+	return len(a) == len(b) && (len(a) == 0 || reflect.DeepEqual(a, b))
+}
@@ -0,0 +1,129 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+// Diff from the definition in primary store, which eventing nodes load on deploy, to the draft
+type draftDiff struct {
+	InPrimaryStore bool `json:"in_primary_store"`
+	appDiff
+}
+
+type functionListEntry struct {
+	application
+	Drift *bool `json:"drift,omitempty"` // Only when asked for, as it reads every function from primary store
+}
+
+// Function missing in primary store has drifted, as its draft was never saved there
+func (m *ServiceMgr) diffDraft(draft application, inPrimaryStore map[string]struct{}) draftDiff {
+	if _, ok := inPrimaryStore[draft.Name]; !ok {
+		return draftDiff{appDiff: diffApplications(application{}, draft, draft.Name+" (primary store)", draft.Name+" (draft)")}
+	}
+
+	primary, info := m.getPrimaryStore(draft.Name)
+	if info.Code != m.statusCodes.ok.Code {
+		logging.Errorf("ServiceMgr::diffDraft %s", info.Info)
+	}
+
+	return draftDiff{
+		InPrimaryStore: true,
+		appDiff:        diffApplications(primary, draft, draft.Name+" (primary store)", draft.Name+" (draft)"),
+	}
+}
+
+// Cheaper counterpart of diffDraft for when only whether the draft changed matters
+func (m *ServiceMgr) hasDrifted(draft application, inPrimaryStore map[string]struct{}) bool {
+	if _, ok := inPrimaryStore[draft.Name]; !ok {
+		return true
+	}
+
+	primary, info := m.getPrimaryStore(draft.Name)
+	if info.Code != m.statusCodes.ok.Code {
+		logging.Errorf("ServiceMgr::hasDrifted %s", info.Info)
+	}
+
+	return !sameApplication(primary, draft)
+}
+
+func (m *ServiceMgr) primaryStoreApps() map[string]struct{} {
+	apps := make(map[string]struct{})
+	for _, appName := range util.ListChildren(metakvAppsPath) {
+		apps[appName] = struct{}{}
+	}
+	return apps
+}
+
+func (m *ServiceMgr) draftDiffHandler(w http.ResponseWriter, r *http.Request, appName string) {
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.FetchDrafts, r, appName)
+
+	draft, info := m.getTempStore(appName)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	response, err := json.Marshal(m.diffDraft(draft, m.primaryStoreApps()))
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal draft diff, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
+
+// Lists drafts of functions, along with whether they have drifted from primary store if asked for
+func (m *ServiceMgr) functionListHandler(w http.ResponseWriter, r *http.Request) {
+	audit.Log(auditevent.FetchDrafts, r, nil)
+
+	params := r.URL.Query()
+	labels := params["label"]
+
+	var inPrimaryStore map[string]struct{}
+	withDrift := params.Get("drift") == "true"
+	if withDrift {
+		inPrimaryStore = m.primaryStoreApps()
+	}
+
+	functions := make([]functionListEntry, 0)
+	for _, app := range m.getTempStoreAll() {
+		if app.Name == "" || !matchesLabels(app.Labels, labels) {
+			continue
+		}
+
+		entry := functionListEntry{application: app}
+		if withDrift {
+			drift := m.hasDrifted(app, inPrimaryStore)
+			entry.Drift = &drift
+		}
+		functions = append(functions, entry)
+	}
+
+	response, err := json.Marshal(functions)
+	if err != nil {
+		info := &runtimeInfo{}
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal functions, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
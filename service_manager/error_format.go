@@ -0,0 +1,160 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/couchbase/cbauth/metakv"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+// Errors are sent as v2 envelope, unless error_format in config asks for the
+// format that predates it
+const (
+	errorFormatV1 = "v1"
+	errorFormatV2 = "v2"
+)
+
+type errorEnvelope struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code        string `json:"code"` // stable name of the error, e.g. ERR_INVALID_CONFIG
+	Status      int    `json:"status"`
+	Message     string `json:"message"`
+	Description string `json:"description"`
+	Field       string `json:"field,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+	Retryable   bool   `json:"retryable"`
+	LegacyCode  int    `json:"legacy_code"`
+}
+
+// Cached by watchConfig, so that a change to config applies to all eventing nodes
+// without a metakv lookup on every error
+func (m *ServiceMgr) errorFormat() string {
+	if format, ok := m.errFormat.Load().(string); ok {
+		return format
+	}
+	return errorFormatV2
+}
+
+func (m *ServiceMgr) setErrorFormat(data []byte) {
+	format := errorFormatV2
+
+	var c config
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &c); err != nil {
+			logging.Errorf("ServiceMgr::setErrorFormat Failed to unmarshal config, err: %v", err)
+		} else if c.ErrorFormat != "" {
+			format = c.ErrorFormat
+		}
+	}
+
+	m.errFormat.Store(format)
+}
+
+func (m *ServiceMgr) watchConfig() {
+	cancelCh := make(chan struct{})
+	for {
+		err := metakv.RunObserveChildren(metakvConfigDir, m.configChangeCallback, cancelCh)
+		if err != nil {
+			logging.Errorf("ServiceMgr::watchConfig metakv observe error for config, err: %v. Retrying...", err)
+			time.Sleep(2 * time.Second)
+		}
+	}
+}
+
+func (m *ServiceMgr) configChangeCallback(path string, value []byte, rev interface{}) error {
+	if path == metakvConfigPath {
+		logging.Infof("ServiceMgr::configChangeCallback Path => %s value => %s", path, string(value))
+		m.setErrorFormat(value)
+	}
+	return nil
+}
+
+func (m *ServiceMgr) errorEnvelope(info *runtimeInfo) errorEnvelope {
+	errInfo := m.errorCodes[info.Code]
+
+	return errorEnvelope{
+		Error: errorDetail{
+			Code:        errInfo.Name,
+			Status:      m.getDisposition(info.Code),
+			Message:     info.Info,
+			Description: errInfo.Description,
+			Field:       info.Field,
+			Remediation: errInfo.Remediation,
+			Retryable:   util.Contains("retry", errInfo.Attributes),
+			LegacyCode:  info.Code,
+		},
+	}
+}
+
+func (m *ServiceMgr) sendErrorEnvelope(w http.ResponseWriter, info *runtimeInfo) {
+	envelope := m.errorEnvelope(info)
+
+	response, err := json.Marshal(envelope)
+	if err != nil {
+		logging.Errorf("ServiceMgr::sendErrorEnvelope Failed to marshal error envelope, err: %v", err)
+		envelope = m.errorEnvelope(&runtimeInfo{
+			Code: m.statusCodes.errMarshalResp.Code,
+			Info: fmt.Sprintf("Failed to marshal error envelope, err: %v", err),
+		})
+		response, _ = json.Marshal(envelope)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Add(headerKey, strconv.Itoa(info.Code))
+	w.WriteHeader(envelope.Error.Status)
+	fmt.Fprintf(w, "%s", string(response))
+}
+
+// Internal routes used to reply with error code in header and plain text in body,
+// which v1 format keeps
+func (m *ServiceMgr) sendPlainErrorInfo(w http.ResponseWriter, info *runtimeInfo) {
+	m.sendPlainError(w, info, false)
+}
+
+// As sendPlainErrorInfo, for routes which also set HTTP status in v1 format
+func (m *ServiceMgr) sendPlainErrorStatus(w http.ResponseWriter, info *runtimeInfo) {
+	m.sendPlainError(w, info, true)
+}
+
+func (m *ServiceMgr) sendPlainError(w http.ResponseWriter, info *runtimeInfo, setStatus bool) {
+	if m.errorFormat() != errorFormatV1 {
+		m.sendErrorEnvelope(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(info.Code))
+	if setStatus {
+		w.WriteHeader(m.getDisposition(info.Code))
+	}
+	fmt.Fprintf(w, "%s", info.Info)
+}
+
+func (m *ServiceMgr) sendAuthError(w http.ResponseWriter, code int, reason string) {
+	if m.errorFormat() == errorFormatV1 {
+		w.WriteHeader(m.getDisposition(code))
+		fmt.Fprintln(w, `{"error":"Request not authorized"}`)
+		return
+	}
+
+	m.sendErrorEnvelope(w, &runtimeInfo{Code: code, Info: reason})
+}
+
+func (m *ServiceMgr) sendMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if m.errorFormat() == errorFormatV1 {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	m.sendErrorEnvelope(w, &runtimeInfo{
+		Code: m.statusCodes.errMethodNotAllowed.Code,
+		Info: fmt.Sprintf("Method %s isn't allowed on %s", r.Method, r.URL.Path),
+	})
+}
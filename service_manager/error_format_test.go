@@ -0,0 +1,37 @@
+package servicemanager
+
+import (
+	"testing"
+)
+
+func TestConfigChangeCallback(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		value []byte
+		want  string
+	}{
+		{"v1", metakvConfigPath, []byte(`{"ram_quota":256,"error_format":"v1"}`), errorFormatV1},
+		{"v2", metakvConfigPath, []byte(`{"error_format":"v2"}`), errorFormatV2},
+		{"not set in config", metakvConfigPath, []byte(`{"ram_quota":256}`), errorFormatV2},
+		{"config deleted", metakvConfigPath, nil, errorFormatV2},
+		{"malformed config", metakvConfigPath, []byte(`{`), errorFormatV2},
+		{"other config", metakvConfigKeepNodes, []byte(`["abc"]`), errorFormatV1},
+	}
+
+	if got := (&ServiceMgr{}).errorFormat(); got != errorFormatV2 {
+		t.Errorf("unset: got %s want %s", got, errorFormatV2)
+	}
+
+	for _, test := range tests {
+		m := &ServiceMgr{}
+		m.errFormat.Store(errorFormatV1)
+		if err := m.configChangeCallback(test.path, test.value, nil); err != nil {
+			t.Errorf("%s: got err %v", test.name, err)
+		}
+
+		if got := m.errorFormat(); got != test.want {
+			t.Errorf("%s: got %s want %s", test.name, got, test.want)
+		}
+	}
+}
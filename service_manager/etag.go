@@ -0,0 +1,95 @@
+package servicemanager
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+// Metakv revisions of the keys a function is stored across. Settings and labels are rewritten
+// on every change made through REST, and those writes are conditional on the revisions read,
+// while the draft checksum also changes when a draft is saved through the UI.
+type appRevs struct {
+	settings interface{}
+	labels   interface{}
+	draft    interface{}
+}
+
+// Revisions must be read before the function, so that a change made in between fails the
+// conditional write rather than going unnoticed
+func (m *ServiceMgr) getAppRevs(appName string) (revs appRevs) {
+	revs.settings = getMetakvRev(metakvAppSettingsPath + appName)
+	revs.labels = getMetakvRev(metakvAppLabelsPath + appName)
+	revs.draft = getMetakvRev(metakvTempChecksumPath + appName)
+	return
+}
+
+func getMetakvRev(path string) interface{} {
+	_, rev, err := util.MetakvGetWithRev(path)
+	if err != nil {
+		logging.Errorf("Failed to read revision of %s from metakv, err: %v", path, err)
+		return nil
+	}
+	return rev
+}
+
+func revsETag(revs ...interface{}) string {
+	return fmt.Sprintf("\"%s\"", util.GetHash(fmt.Sprintf("%v", revs)))
+}
+
+// Returns ETag of the function definition including its labels, empty if function doesn't exist
+func (revs appRevs) appETag() string {
+	if revs.draft == nil {
+		return ""
+	}
+	return revsETag(revs.settings, revs.labels, revs.draft)
+}
+
+// Returns ETag of the function settings, empty if function doesn't exist. Drafts saved
+// through the UI alone don't have settings in primary store yet.
+func (revs appRevs) settingsETag() string {
+	if revs.draft == nil {
+		return ""
+	}
+	if revs.settings == nil {
+		return revsETag(revs.draft)
+	}
+	return revsETag(revs.settings)
+}
+
+// Write is allowed if request carries no If-Match, or one of the supplied ETags matches
+// the current one. Weak ETags never match as If-Match requires strong comparison.
+func (m *ServiceMgr) checkIfMatch(r *http.Request, current string) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+	info.Code = m.statusCodes.ok.Code
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return
+	}
+
+	if current != "" {
+		for _, tag := range strings.Split(ifMatch, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || tag == current {
+				return
+			}
+		}
+	}
+
+	info.Code = m.statusCodes.errPreconditionFailed.Code
+	info.Info = fmt.Sprintf("If-Match: %s doesn't match current ETag: %s", ifMatch, current)
+	return
+}
+
+// A conditional write fails when the function was changed, possibly on another node, after
+// its revisions were read
+func (m *ServiceMgr) revMismatchInfo(appName string) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+	info.Code = m.statusCodes.errPreconditionFailed.Code
+	info.Info = fmt.Sprintf("Function: %s was changed while the request was processed, retry the request", appName)
+	return
+}
@@ -0,0 +1,74 @@
+package servicemanager
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAppRevsETag(t *testing.T) {
+	revs := appRevs{settings: "s1", labels: "l1", draft: "d1"}
+
+	tests := []struct {
+		name         string
+		revs         appRevs
+		sameApp      bool
+		sameSettings bool
+	}{
+		{"unchanged", appRevs{settings: "s1", labels: "l1", draft: "d1"}, true, true},
+		{"settings changed", appRevs{settings: "s2", labels: "l1", draft: "d1"}, false, false},
+		{"labels changed", appRevs{settings: "s1", labels: "l2", draft: "d1"}, false, true},
+		{"draft saved", appRevs{settings: "s1", labels: "l1", draft: "d2"}, false, true},
+	}
+
+	for _, test := range tests {
+		if got := test.revs.appETag() == revs.appETag(); got != test.sameApp {
+			t.Errorf("%s: got same function ETag %v want %v", test.name, got, test.sameApp)
+		}
+		if got := test.revs.settingsETag() == revs.settingsETag(); got != test.sameSettings {
+			t.Errorf("%s: got same settings ETag %v want %v", test.name, got, test.sameSettings)
+		}
+	}
+
+	missing := appRevs{settings: "s1", labels: "l1"}
+	if missing.appETag() != "" || missing.settingsETag() != "" {
+		t.Errorf("missing function: got ETags %s and %s want empty", missing.appETag(), missing.settingsETag())
+	}
+
+	draftOnly := appRevs{draft: "d1"}
+	if draftOnly.settingsETag() == "" || draftOnly.settingsETag() == (appRevs{draft: "d2"}).settingsETag() {
+		t.Errorf("draft only: got settings ETag %s want one that follows the draft", draftOnly.settingsETag())
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	m := &ServiceMgr{}
+	m.initErrCodes()
+
+	current := appRevs{settings: "s1", labels: "l1", draft: "d1"}.appETag()
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		current string
+		want    int
+	}{
+		{"no If-Match", "", current, m.statusCodes.ok.Code},
+		{"match", current, current, m.statusCodes.ok.Code},
+		{"one of many", `"other", ` + current, current, m.statusCodes.ok.Code},
+		{"any", "*", current, m.statusCodes.ok.Code},
+		{"mismatch", `"other"`, current, m.statusCodes.errPreconditionFailed.Code},
+		{"weak", "W/" + current, current, m.statusCodes.errPreconditionFailed.Code},
+		{"missing function", "*", "", m.statusCodes.errPreconditionFailed.Code},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest("POST", "/api/v1/functions/f1", nil)
+		if test.ifMatch != "" {
+			r.Header.Set("If-Match", test.ifMatch)
+		}
+
+		if got := m.checkIfMatch(r, test.current); got.Code != test.want {
+			t.Errorf("%s: got %d want %d", test.name, got.Code, test.want)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+const (
+	bundleVersion = 1
+
+	importOnConflictFail   = "fail"
+	importOnConflictRename = "rename"
+	importOnConflictSkip   = "skip"
+)
+
+type bundle struct {
+	Version    int           `json:"version"`
+	ExportedAt string        `json:"exported_at"`
+	Config     config        `json:"config"`
+	Functions  []application `json:"functions"`
+}
+
+// Collects every function along with its settings and the global config.
+// Definition from primary store is preferred, drafts are included for functions
+// which haven't made it to primary store.
+func (m *ServiceMgr) exportBundle() (b bundle, info *runtimeInfo) {
+	logPrefix := "ServiceMgr::exportBundle"
+
+	c, info := m.getConfig()
+	if info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
+	b.Version = bundleVersion
+	b.ExportedAt = time.Now().Format(time.RFC3339)
+	b.Config = c
+	b.Functions = make([]application, 0)
+
+	exported := make(map[string]struct{})
+	for _, appName := range util.ListChildren(metakvAppsPath) {
+		app, info := m.getPrimaryStore(appName)
+		if info.Code != m.statusCodes.ok.Code {
+			logging.Errorf("%s %s", logPrefix, info.Info)
+			continue
+		}
+
+		b.Functions = append(b.Functions, app)
+		exported[app.Name] = struct{}{}
+	}
+
+	for _, app := range m.getTempStoreAll() {
+		if app.Name == "" {
+			continue
+		}
+
+		if _, ok := exported[app.Name]; !ok {
+			b.Functions = append(b.Functions, app)
+			exported[app.Name] = struct{}{}
+		}
+	}
+
+	logging.Infof("%s Exported %d functions", logPrefix, len(b.Functions))
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+// Function of the bundle to be imported, under the name it's imported as
+type importTarget struct {
+	index int // Position in bundle
+	app   application
+}
+
+type importPlan struct {
+	targets      []importTarget
+	importConfig bool
+	infoList     []*runtimeInfo // One per function in bundle, followed by one for global config
+	failed       bool
+}
+
+// Decides what import does with each function of the bundle and with global config, as per
+// onConflict. Functions are reset to undeployed and validated, but neither compiled nor checked
+// against buckets. Names taken are added to existing.
+func (m *ServiceMgr) planImport(b bundle, existing map[string]struct{}, onConflict string) (plan importPlan) {
+	logPrefix := "ServiceMgr::planImport"
+
+	plan.infoList = make([]*runtimeInfo, 0, len(b.Functions)+1)
+
+	for i, app := range b.Functions {
+		info := &runtimeInfo{}
+		plan.infoList = append(plan.infoList, info)
+
+		if _, ok := existing[app.Name]; ok {
+			switch onConflict {
+			case importOnConflictSkip:
+				info.Code = m.statusCodes.ok.Code
+				info.Info = fmt.Sprintf("Function: %s already exists, skipping", app.Name)
+				continue
+
+			case importOnConflictRename:
+				newName := m.uniqueAppName(app.Name, existing)
+				logging.Infof("%s Function: %s already exists, importing as: %s", logPrefix, app.Name, newName)
+				app.Name = newName
+
+			default:
+				info.Code = m.statusCodes.errFunctionExists.Code
+				info.Info = fmt.Sprintf("Function: %s already exists", app.Name)
+				plan.failed = true
+				continue
+			}
+		}
+
+		settings := make(map[string]interface{}, len(app.Settings))
+		for key, val := range app.Settings {
+			settings[key] = val
+		}
+		settings["deployment_status"] = false
+		settings["processing_status"] = false
+		app.Settings = settings
+
+		if vInfo := m.validateApplication(&app); vInfo.Code != m.statusCodes.ok.Code {
+			*info = *vInfo
+			info.Info = fmt.Sprintf("Function: %s %s", app.Name, vInfo.Info)
+			plan.failed = true
+			continue
+		}
+
+		existing[app.Name] = struct{}{}
+		plan.targets = append(plan.targets, importTarget{index: i, app: app})
+
+		info.Code = m.statusCodes.ok.Code
+		info.Info = fmt.Sprintf("Function: %s would be imported", app.Name)
+	}
+
+	configInfo := &runtimeInfo{Code: m.statusCodes.ok.Code}
+	plan.infoList = append(plan.infoList, configInfo)
+
+	if onConflict == importOnConflictSkip && len(plan.targets) == 0 && !plan.failed {
+		configInfo.Info = "Every function skipped, skipping global config"
+	} else {
+		plan.importConfig = true
+		configInfo.Info = "Global config would be imported"
+	}
+
+	return
+}
+
+// Marks everything planned for import as not imported because of reason
+func (m *ServiceMgr) abortImport(plan importPlan, reason string) {
+	for _, target := range plan.targets {
+		info := plan.infoList[target.index]
+		if info.Code != m.statusCodes.ok.Code {
+			continue
+		}
+
+		info.Code = m.statusCodes.errImportAborted.Code
+		info.Info = fmt.Sprintf("Function: %s not imported as %s", target.app.Name, reason)
+	}
+
+	if plan.importConfig {
+		configInfo := plan.infoList[len(plan.infoList)-1]
+		configInfo.Code = m.statusCodes.errImportAborted.Code
+		configInfo.Info = fmt.Sprintf("Global config not imported as %s", reason)
+	}
+}
+
+// Removes functions stored by an import which failed part way. Functions which can't be
+// removed are reported as left behind, for them to be deleted by hand.
+func (m *ServiceMgr) rollbackImport(plan importPlan, stored []importTarget, reason string) {
+	logPrefix := "ServiceMgr::rollbackImport"
+
+	for _, target := range stored {
+		info := plan.infoList[target.index]
+		if info.Code == m.statusCodes.ok.Code {
+			info.Code = m.statusCodes.errImportAborted.Code
+			info.Info = fmt.Sprintf("Function: %s import rolled back as %s", target.app.Name, reason)
+		}
+
+		dInfo := m.deletePrimaryStore(target.app.Name, m.getAppRevs(target.app.Name))
+		if dInfo.Code == m.statusCodes.ok.Code {
+			dInfo = m.deleteTempStore(target.app.Name)
+		}
+
+		if dInfo.Code != m.statusCodes.ok.Code {
+			logging.Errorf("%s Function: %s rollback failed: %s", logPrefix, target.app.Name, dInfo.Info)
+			info.Info = fmt.Sprintf("%s, rollback failed and function is left behind: %s", info.Info, dInfo.Info)
+		}
+	}
+
+	m.abortImport(plan, reason)
+}
+
+// Imports functions from bundle all or nothing. Every function is validated and compiled
+// before anything is stored. Functions are stored next and global config last, a failure
+// along the way rolls back what the import has stored. Imported functions are always undeployed.
+func (m *ServiceMgr) importBundle(r *http.Request, b bundle, dryRun bool, onConflict string) []*runtimeInfo {
+	logPrefix := "ServiceMgr::importBundle"
+
+	existing := make(map[string]struct{})
+	for _, appName := range util.ListChildren(metakvAppsPath) {
+		existing[appName] = struct{}{}
+	}
+	for _, appName := range util.ListChildren(metakvTempAppsPath) {
+		existing[appName] = struct{}{}
+	}
+
+	plan := m.planImport(b, existing, onConflict)
+
+	for _, target := range plan.targets {
+		info := plan.infoList[target.index]
+		app := target.app
+
+		if vInfo := m.validateBuckets(&app.DeploymentConfig); vInfo.Code != m.statusCodes.ok.Code {
+			*info = *vInfo
+			info.Info = fmt.Sprintf("Function: %s %s", app.Name, vInfo.Info)
+			plan.failed = true
+			continue
+		}
+
+		if _, cInfo := m.compileApp(app); cInfo.Code != m.statusCodes.ok.Code {
+			*info = *cInfo
+			info.Info = fmt.Sprintf("Function: %s %s", app.Name, cInfo.Info)
+			plan.failed = true
+		}
+	}
+
+	if plan.failed {
+		m.abortImport(plan, "bundle failed validation")
+		return plan.infoList
+	}
+
+	if dryRun {
+		return plan.infoList
+	}
+
+	stored := make([]importTarget, 0, len(plan.targets))
+	for _, target := range plan.targets {
+		app := target.app
+
+		audit.Log(auditevent.CreateFunction, r, app.Name)
+
+		info := m.savePrimaryStore(app, m.getAppRevs(app.Name))
+		if info.Code == m.statusCodes.ok.Code {
+			stored = append(stored, target)
+			audit.Log(auditevent.SaveDraft, r, app.Name)
+			info = m.saveTempStore(app)
+		}
+
+		if info.Code != m.statusCodes.ok.Code {
+			logging.Errorf("%s Function: %s failed to be stored, rolling back import: %s", logPrefix, app.Name, info.Info)
+			*plan.infoList[target.index] = *info
+			m.rollbackImport(plan, stored, fmt.Sprintf("function: %s failed to be stored", app.Name))
+			return plan.infoList
+		}
+
+		m.recordRevision(app, getAuthor(r), 0)
+		plan.infoList[target.index].Info = fmt.Sprintf("Imported function: %s", app.Name)
+	}
+
+	if plan.importConfig {
+		m.auditConfigChange(r, b.Config)
+
+		if info := m.saveConfig(b.Config); info.Code != m.statusCodes.ok.Code {
+			logging.Errorf("%s Failed to store global config, rolling back import: %s", logPrefix, info.Info)
+			*plan.infoList[len(plan.infoList)-1] = *info
+			plan.importConfig = false
+			m.rollbackImport(plan, stored, "global config failed to be stored")
+			return plan.infoList
+		}
+
+		plan.infoList[len(plan.infoList)-1].Info = "Imported global config"
+	}
+
+	return plan.infoList
+}
+
+func (m *ServiceMgr) validateBundle(b bundle) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+
+	if b.Version != bundleVersion {
+		info.Code = m.statusCodes.errInvalidConfig.Code
+		info.Info = fmt.Sprintf("Unsupported bundle version: %d, expected: %d", b.Version, bundleVersion)
+		info.Field = "version"
+		return
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+// Returns first name of form <appName>_<n> which isn't in use
+func (m *ServiceMgr) uniqueAppName(appName string, existing map[string]struct{}) string {
+	for n := 1; ; n++ {
+		suffix := "_" + strconv.Itoa(n)
+
+		base := appName
+		if len(base)+len(suffix) > maxApplicationNameLength {
+			base = base[:maxApplicationNameLength-len(suffix)]
+		}
+
+		if _, ok := existing[base+suffix]; !ok {
+			return base + suffix
+		}
+	}
+}
+
+func (m *ServiceMgr) exportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !m.validateAuth(w, r, EventingPermissionRead) {
+		return
+	}
+
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.FetchFunctions, r, nil)
+
+	b, info := m.exportBundle()
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	response, err := json.Marshal(b)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal bundle, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
+
+func (m *ServiceMgr) importHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !m.validateAuth(w, r, EventingPermissionManage) {
+		return
+	}
+
+	if r.Method != "POST" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	info := &runtimeInfo{}
+	params := r.URL.Query()
+
+	dryRun := params.Get("dry_run") == "true"
+
+	onConflict := params.Get("on_conflict")
+	if onConflict == "" {
+		onConflict = importOnConflictFail
+	}
+
+	if onConflict != importOnConflictFail && onConflict != importOnConflictRename && onConflict != importOnConflictSkip {
+		info.Code = m.statusCodes.errInvalidConfig.Code
+		info.Info = fmt.Sprintf("on_conflict must be one of %s, %s, %s",
+			importOnConflictFail, importOnConflictRename, importOnConflictSkip)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		info.Code = m.statusCodes.errReadReq.Code
+		info.Info = fmt.Sprintf("Failed to read request body, err: %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	var b bundle
+	err = json.Unmarshal(data, &b)
+	if err != nil {
+		info.Code = m.statusCodes.errUnmarshalPld.Code
+		info.Info = fmt.Sprintf("Failed to unmarshal bundle, err: %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	if info = m.validateBundle(b); info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	if rebStatus := m.checkRebalanceStatus(); rebStatus.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, rebStatus)
+		return
+	}
+
+	m.sendRuntimeInfoList(w, m.importBundle(r, b, dryRun, onConflict))
+}
@@ -0,0 +1,202 @@
+package servicemanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanImport(t *testing.T) {
+	app := func(name string) application {
+		return application{
+			Name:             name,
+			AppHandlers:      "function OnUpdate(doc, meta) {}",
+			DeploymentConfig: depCfg{SourceBucket: "src", MetadataBucket: "meta"},
+			Settings: map[string]interface{}{
+				"deployment_status": true,
+				"processing_status": true,
+			},
+		}
+	}
+
+	m := &ServiceMgr{}
+	m.initErrCodes()
+	ok := m.statusCodes.ok.Code
+	exists := m.statusCodes.errFunctionExists.Code
+	invalid := m.statusCodes.errInvalidConfig.Code
+
+	tests := []struct {
+		name         string
+		functions    []application
+		onConflict   string
+		wantTargets  []string
+		wantCodes    []int // Per function followed by global config
+		wantConfig   bool
+		wantFailed   bool
+		wantExisting []string
+	}{
+		{
+			name:        "no conflict",
+			functions:   []application{app("f1"), app("f2")},
+			onConflict:  importOnConflictFail,
+			wantTargets: []string{"f1", "f2"},
+			wantCodes:   []int{ok, ok, ok},
+			wantConfig:  true,
+		},
+		{
+			name:        "conflict fails",
+			functions:   []application{app("f1"), app("taken")},
+			onConflict:  importOnConflictFail,
+			wantTargets: []string{"f1"},
+			wantCodes:   []int{ok, exists, ok},
+			wantConfig:  true,
+			wantFailed:  true,
+		},
+		{
+			name:         "conflict renamed",
+			functions:    []application{app("taken"), app("taken")},
+			onConflict:   importOnConflictRename,
+			wantTargets:  []string{"taken_2", "taken_3"},
+			wantCodes:    []int{ok, ok, ok},
+			wantConfig:   true,
+			wantExisting: []string{"taken_2", "taken_3"},
+		},
+		{
+			name:        "conflict skipped",
+			functions:   []application{app("taken"), app("f1")},
+			onConflict:  importOnConflictSkip,
+			wantTargets: []string{"f1"},
+			wantCodes:   []int{ok, ok, ok},
+			wantConfig:  true,
+		},
+		{
+			name:        "every function skipped",
+			functions:   []application{app("taken")},
+			onConflict:  importOnConflictSkip,
+			wantTargets: []string{},
+			wantCodes:   []int{ok, ok},
+			wantConfig:  false,
+		},
+		{
+			name:        "empty bundle",
+			functions:   []application{},
+			onConflict:  importOnConflictSkip,
+			wantTargets: []string{},
+			wantCodes:   []int{ok},
+			wantConfig:  false,
+		},
+		{
+			name:        "invalid function",
+			functions:   []application{app("f1"), app("")},
+			onConflict:  importOnConflictRename,
+			wantTargets: []string{"f1"},
+			wantCodes:   []int{ok, invalid, ok},
+			wantConfig:  true,
+			wantFailed:  true,
+		},
+	}
+
+	for _, test := range tests {
+		existing := map[string]struct{}{"taken": {}, "taken_1": {}}
+		plan := m.planImport(bundle{Version: bundleVersion, Functions: test.functions}, existing, test.onConflict)
+
+		targets := make([]string, 0)
+		for _, target := range plan.targets {
+			targets = append(targets, target.app.Name)
+
+			if target.app.Settings["deployment_status"] != false || target.app.Settings["processing_status"] != false {
+				t.Errorf("%s: %s got settings %v want undeployed", test.name, target.app.Name, target.app.Settings)
+			}
+			if test.functions[target.index].Settings["deployment_status"] != true {
+				t.Errorf("%s: %s settings in bundle modified", test.name, target.app.Name)
+			}
+		}
+		if !reflect.DeepEqual(targets, test.wantTargets) {
+			t.Errorf("%s: got targets %v want %v", test.name, targets, test.wantTargets)
+		}
+
+		codes := make([]int, 0)
+		for _, info := range plan.infoList {
+			codes = append(codes, info.Code)
+		}
+		if !reflect.DeepEqual(codes, test.wantCodes) {
+			t.Errorf("%s: got codes %v want %v", test.name, codes, test.wantCodes)
+		}
+
+		if plan.importConfig != test.wantConfig {
+			t.Errorf("%s: got import config %v want %v", test.name, plan.importConfig, test.wantConfig)
+		}
+		if plan.failed != test.wantFailed {
+			t.Errorf("%s: got failed %v want %v", test.name, plan.failed, test.wantFailed)
+		}
+		for _, name := range test.wantExisting {
+			if _, ok := existing[name]; !ok {
+				t.Errorf("%s: %s not marked as taken", test.name, name)
+			}
+		}
+	}
+}
+
+func TestAbortImport(t *testing.T) {
+	m := &ServiceMgr{}
+	m.initErrCodes()
+	ok := m.statusCodes.ok.Code
+	aborted := m.statusCodes.errImportAborted.Code
+
+	tests := []struct {
+		name       string
+		onConflict string
+		functions  []string
+		wantCodes  []int
+	}{
+		{"conflict fails", importOnConflictFail, []string{"f1", "taken", "f2"},
+			[]int{aborted, m.statusCodes.errFunctionExists.Code, aborted, aborted}},
+		{"skipped left alone", importOnConflictSkip, []string{"f1", "taken", ""},
+			[]int{aborted, ok, m.statusCodes.errInvalidConfig.Code, aborted}},
+	}
+
+	for _, test := range tests {
+		functions := make([]application, 0)
+		for _, name := range test.functions {
+			functions = append(functions, application{
+				Name:             name,
+				AppHandlers:      "function OnUpdate(doc, meta) {}",
+				DeploymentConfig: depCfg{SourceBucket: "src", MetadataBucket: "meta"},
+			})
+		}
+
+		plan := m.planImport(bundle{Functions: functions}, map[string]struct{}{"taken": {}}, test.onConflict)
+		if !plan.failed {
+			t.Fatalf("%s: got failed false want true", test.name)
+		}
+		m.abortImport(plan, "bundle failed validation")
+
+		codes := make([]int, 0)
+		for _, info := range plan.infoList {
+			codes = append(codes, info.Code)
+		}
+		if !reflect.DeepEqual(codes, test.wantCodes) {
+			t.Errorf("%s: got codes %v want %v", test.name, codes, test.wantCodes)
+		}
+	}
+}
+
+func TestValidateBundle(t *testing.T) {
+	m := &ServiceMgr{}
+	m.initErrCodes()
+
+	tests := []struct {
+		name    string
+		version int
+		want    int
+	}{
+		{"current version", bundleVersion, m.statusCodes.ok.Code},
+		{"missing version", 0, m.statusCodes.errInvalidConfig.Code},
+		{"newer version", bundleVersion + 1, m.statusCodes.errInvalidConfig.Code},
+	}
+
+	for _, test := range tests {
+		if got := m.validateBundle(bundle{Version: test.version}); got.Code != test.want {
+			t.Errorf("%s: got %d want %d", test.name, got.Code, test.want)
+		}
+	}
+}
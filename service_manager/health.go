@@ -0,0 +1,137 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/couchbase/eventing/util"
+)
+
+const (
+	functionStatusBootstrapping = "bootstrapping"
+	functionStatusRunning       = "running"
+)
+
+type functionHealth struct {
+	Status          string         `json:"status"`
+	Rebalancing     bool           `json:"rebalancing"`
+	Paused          bool           `json:"paused"`
+	DcpBacklog      uint64         `json:"dcp_backlog"`
+	BacklogExceeded bool           `json:"backlog_exceeded"`
+	WorkerPids      map[string]int `json:"worker_pids"`
+	WorkersAlive    bool           `json:"workers_alive"`
+	Healthy         bool           `json:"healthy"`
+}
+
+type nodeHealth struct {
+	NodeUUID         string                     `json:"node_uuid"`
+	Healthy          bool                       `json:"healthy"`
+	RebalanceRunning bool                       `json:"rebalance_running"`
+	BacklogThreshold uint64                     `json:"backlog_threshold"`
+	Functions        map[string]*functionHealth `json:"functions"`
+}
+
+// Function is healthy once it's done bootstrapping, all of its workers are up and its
+// DCP backlog is within threshold. Backlog of a paused function is bound to grow, hence
+// it's ignored like rebalance, which is reported but doesn't affect health.
+func (m *ServiceMgr) functionHealth(appName string, bootstrapping bool, backlogThreshold uint64) *functionHealth {
+	health := &functionHealth{
+		Status:      functionStatusRunning,
+		Rebalancing: m.superSup.GetRebalanceStatus(appName),
+		Paused:      m.superSup.IsProcessingPaused(appName),
+		DcpBacklog:  m.superSup.GetDcpEventsRemainingToProcess(appName),
+		WorkerPids:  m.superSup.GetEventingConsumerPids(appName),
+	}
+
+	if bootstrapping {
+		health.Status = functionStatusBootstrapping
+	}
+
+	health.BacklogExceeded = health.DcpBacklog > backlogThreshold
+
+	health.WorkersAlive = len(health.WorkerPids) > 0
+	for _, pid := range health.WorkerPids {
+		if !util.ProcessAlive(pid) {
+			health.WorkersAlive = false
+		}
+	}
+
+	health.Healthy = !bootstrapping && health.WorkersAlive && (health.Paused || !health.BacklogExceeded)
+	return health
+}
+
+func (m *ServiceMgr) nodeHealth(backlogThreshold uint64) *nodeHealth {
+	health := &nodeHealth{
+		NodeUUID:         m.uuid,
+		Healthy:          true,
+		BacklogThreshold: backlogThreshold,
+		Functions:        make(map[string]*functionHealth),
+	}
+
+	bootstrapping := m.superSup.BootstrapAppList()
+
+	appNames := m.superSup.DeployedAppList()
+	for appName := range bootstrapping {
+		appNames = append(appNames, appName)
+	}
+
+	for _, appName := range appNames {
+		if _, ok := health.Functions[appName]; ok {
+			continue
+		}
+
+		_, isBootstrapping := bootstrapping[appName]
+		fHealth := m.functionHealth(appName, isBootstrapping, backlogThreshold)
+
+		health.Functions[appName] = fHealth
+		health.Healthy = health.Healthy && fHealth.Healthy
+		health.RebalanceRunning = health.RebalanceRunning || fHealth.Rebalancing
+	}
+
+	return health
+}
+
+// Meant to be polled by load balancers, hence only looks at state held in memory
+func (m *ServiceMgr) healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !m.validateAuth(w, r, EventingPermissionStats) {
+		return
+	}
+
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	info := &runtimeInfo{}
+
+	backlogThreshold := uint64(defaultHealthBacklogThreshold)
+	if value := r.URL.Query().Get("backlog_threshold"); value != "" {
+		threshold, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			info.Code = m.statusCodes.errInvalidConfig.Code
+			info.Info = fmt.Sprintf("backlog_threshold must be a non-negative number, got: %s", value)
+			m.sendErrorInfo(w, info)
+			return
+		}
+		backlogThreshold = threshold
+	}
+
+	health := m.nodeHealth(backlogThreshold)
+
+	response, err := json.Marshal(health)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal health, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	if !health.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	fmt.Fprintf(w, "%s", string(response))
+}
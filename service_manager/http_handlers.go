@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/couchbase/cbauth"
+	"github.com/couchbase/cbauth/metakv"
 	"github.com/couchbase/eventing/audit"
 	"github.com/couchbase/eventing/common"
 	"github.com/couchbase/eventing/consumer"
@@ -33,7 +34,7 @@ func (m *ServiceMgr) startTracing(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logging.Infof("Got request to start tracing")
-	audit.Log(auditevent.StartTracing, r, nil)
+	audit.Log(auditevent.TracingStarted, r, audit.Context{})
 
 	os.Remove(m.uuid + "_trace.out")
 
@@ -59,13 +60,13 @@ func (m *ServiceMgr) stopTracing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	audit.Log(auditevent.StopTracing, r, nil)
+	audit.Log(auditevent.TracingStopped, r, audit.Context{})
 	logging.Infof("Got request to stop tracing")
 	m.stopTracerCh <- struct{}{}
 }
 
 func (m *ServiceMgr) getNodeUUID(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 	logging.Debugf("Got request to fetch UUID from host %v", r.Host)
@@ -78,27 +79,25 @@ func (m *ServiceMgr) debugging(w http.ResponseWriter, r *http.Request) {
 	if strings.HasSuffix(jsFile, srcCodeExt) {
 		appName := jsFile[:len(jsFile)-len(srcCodeExt)]
 		handler := m.getHandler(appName)
+		if handler == "" {
+			m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %s not deployed", appName)})
+			return
+		}
 
 		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
 		fmt.Fprintf(w, "%s", handler)
-		if handler == "" {
-			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-			fmt.Fprintf(w, "App: %s not deployed", appName)
-		}
 	} else if strings.HasSuffix(jsFile, srcMapExt) {
 		appName := jsFile[:len(jsFile)-len(srcMapExt)]
 		sourceMap := m.getSourceMap(appName)
+		if sourceMap == "" {
+			m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %s not deployed", appName)})
+			return
+		}
 
 		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
 		fmt.Fprintf(w, "%s", sourceMap)
-
-		if sourceMap == "" {
-			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-			fmt.Fprintf(w, "App: %s not deployed", appName)
-		}
 	} else {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errInvalidExt.Code))
-		fmt.Fprintf(w, "Invalid extension for %s", jsFile)
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errInvalidExt.Code, Info: fmt.Sprintf("Invalid extension for %s", jsFile)})
 	}
 }
 
@@ -112,11 +111,13 @@ func (m *ServiceMgr) deletePrimaryStoreHandler(w http.ResponseWriter, r *http.Re
 
 	logging.Infof("Deleting application %v from primary store", appName)
 	audit.Log(auditevent.DeleteFunction, r, appName)
-	m.deletePrimaryStore(appName)
+
+	m.deletePrimaryStore(appName, m.getAppRevs(appName))
 }
 
-// Deletes application from primary store and returns the appropriate success/error code
-func (m *ServiceMgr) deletePrimaryStore(appName string) (info *runtimeInfo) {
+// Deletes application from primary store and returns the appropriate success/error code.
+// Deletion is conditional on the revisions supplied.
+func (m *ServiceMgr) deletePrimaryStore(appName string, revs appRevs) (info *runtimeInfo) {
 	info = &runtimeInfo{}
 	logging.Infof("Deleting application %v from primary store", appName)
 
@@ -141,13 +142,28 @@ func (m *ServiceMgr) deletePrimaryStore(appName string) (info *runtimeInfo) {
 	}
 
 	settingPath := metakvAppSettingsPath + appName
-	err := util.MetaKvDelete(settingPath, nil)
+	err := util.MetaKvDelete(settingPath, revs.settings)
+	if err == metakv.ErrRevMismatch {
+		info = m.revMismatchInfo(appName)
+		return
+	}
 	if err != nil {
 		info.Code = m.statusCodes.errDelAppSettingsPs.Code
 		info.Info = fmt.Sprintf("Failed to delete setting for app: %v, err: %v", appName, err)
 		return
 	}
 
+	err = util.MetaKvDelete(metakvAppLabelsPath+appName, revs.labels)
+	if err == metakv.ErrRevMismatch {
+		info = m.revMismatchInfo(appName)
+		return
+	}
+	if err != nil {
+		info.Code = m.statusCodes.errDelAppPs.Code
+		info.Info = fmt.Sprintf("Failed to delete labels for app: %v, err: %v", appName, err)
+		return
+	}
+
 	err = util.DeleteAppContent(metakvAppsPath, metakvChecksumPath, appName)
 	if err != nil {
 		info.Code = m.statusCodes.errDelAppPs.Code
@@ -204,6 +220,17 @@ func (m *ServiceMgr) deleteTempStore(appName string) (info *runtimeInfo) {
 		info.Info = fmt.Sprintf("Failed to delete App definition : %v, err: %v", appName, err)
 		return
 	}
+
+	// Labels key is shared with primary store, and is left for it to delete if the function is still there
+	if !util.Contains(appName, util.ListChildren(metakvAppsPath)) {
+		if err := util.MetaKvDelete(metakvAppLabelsPath+appName, nil); err != nil {
+			info.Code = m.statusCodes.errDelAppTs.Code
+			info.Info = fmt.Sprintf("Failed to delete labels for app: %v, err: %v", appName, err)
+			return
+		}
+	}
+
+	m.deleteRevisions(appName)
 	info.Code = m.statusCodes.ok.Code
 	info.Info = fmt.Sprintf("Deleting app: %v in the background", appName)
 	return
@@ -226,8 +253,7 @@ func (m *ServiceMgr) getDebuggerURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-	fmt.Fprintf(w, "App: %v not deployed", appName)
+	m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 }
 
 func (m *ServiceMgr) getLocalDebugURL(w http.ResponseWriter, r *http.Request) {
@@ -259,7 +285,7 @@ func (m *ServiceMgr) startDebugger(w http.ResponseWriter, r *http.Request) {
 	appName := values["name"][0]
 
 	logging.Infof("App: %v got request to start V8 debugger", appName)
-	audit.Log(auditevent.StartDebug, r, appName)
+	audit.Log(auditevent.DebuggerStarted, r, audit.Context{Function: appName})
 
 	if m.checkIfDeployed(appName) {
 		m.superSup.SignalStartDebugger(appName)
@@ -268,8 +294,7 @@ func (m *ServiceMgr) startDebugger(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-	fmt.Fprintf(w, "App: %v not deployed", appName)
+	m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 }
 
 func (m *ServiceMgr) stopDebugger(w http.ResponseWriter, r *http.Request) {
@@ -281,7 +306,7 @@ func (m *ServiceMgr) stopDebugger(w http.ResponseWriter, r *http.Request) {
 	appName := values["name"][0]
 
 	logging.Infof("App: %v got request to stop V8 debugger", appName)
-	audit.Log(auditevent.StopDebug, r, appName)
+	audit.Log(auditevent.DebuggerStopped, r, audit.Context{Function: appName})
 
 	if m.checkIfDeployed(appName) {
 		m.superSup.SignalStopDebugger(appName)
@@ -290,12 +315,11 @@ func (m *ServiceMgr) stopDebugger(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-	fmt.Fprintf(w, "App: %v not deployed", appName)
+	m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 }
 
 func (m *ServiceMgr) getEventProcessingStats(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionStats) {
 		return
 	}
 
@@ -307,16 +331,14 @@ func (m *ServiceMgr) getEventProcessingStats(w http.ResponseWriter, r *http.Requ
 
 		data, err := json.Marshal(&stats)
 		if err != nil {
-			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errMarshalResp.Code))
-			fmt.Fprintf(w, "Failed to marshal response event processing stats, err: %v", err)
+			m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errMarshalResp.Code, Info: fmt.Sprintf("Failed to marshal response event processing stats, err: %v", err)})
 			return
 		}
 
 		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
 		fmt.Fprintf(w, "%s", string(data))
 	} else {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-		fmt.Fprintf(w, "App: %v not deployed", appName)
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 	}
 }
 
@@ -338,7 +360,7 @@ var getDeployedAppsCallback = func(args ...interface{}) error {
 
 // Returns list of apps that are deployed i.e. finished dcp/timer/debugger related bootstrap
 func (m *ServiceMgr) getDeployedApps(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -347,8 +369,7 @@ func (m *ServiceMgr) getDeployedApps(w http.ResponseWriter, r *http.Request) {
 	nodeAddrs, err := m.getActiveNodeAddrs()
 	if err != nil {
 		logging.Errorf("Failed to fetch active Eventing nodes, err: %v", err)
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errActiveEventingNodes.Code))
-		fmt.Fprintf(w, "")
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errActiveEventingNodes.Code, Info: fmt.Sprintf("Failed to fetch active Eventing nodes, err: %v", err)})
 		return
 	}
 
@@ -369,8 +390,7 @@ func (m *ServiceMgr) getDeployedApps(w http.ResponseWriter, r *http.Request) {
 
 	numEventingNodes := len(nodeAddrs)
 	if numEventingNodes <= 0 {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errNoEventingNodes.Code))
-		fmt.Fprintf(w, "")
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errNoEventingNodes.Code, Info: "No active Eventing nodes found"})
 		return
 	}
 
@@ -384,8 +404,7 @@ func (m *ServiceMgr) getDeployedApps(w http.ResponseWriter, r *http.Request) {
 	buf, err := json.Marshal(deployedApps)
 	if err != nil {
 		logging.Errorf("Failed to marshal list of deployed apps, err: %v", err)
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errMarshalResp.Code))
-		fmt.Fprintf(w, "")
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errMarshalResp.Code, Info: fmt.Sprintf("Failed to marshal list of deployed apps, err: %v", err)})
 		return
 	}
 
@@ -394,7 +413,7 @@ func (m *ServiceMgr) getDeployedApps(w http.ResponseWriter, r *http.Request) {
 }
 
 func (m *ServiceMgr) getLocallyDeployedApps(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -419,7 +438,7 @@ func (m *ServiceMgr) getNamedParamsHandler(w http.ResponseWriter, r *http.Reques
 
 	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errReadReq.Code))
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errReadReq.Code, Info: fmt.Sprintf("Failed to read request body, err: %v", err)})
 		return
 	}
 
@@ -440,7 +459,7 @@ func (m *ServiceMgr) getNamedParamsHandler(w http.ResponseWriter, r *http.Reques
 
 // Reports progress across all producers on current node
 func (m *ServiceMgr) getRebalanceProgress(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -470,7 +489,7 @@ func (m *ServiceMgr) getRebalanceProgress(w http.ResponseWriter, r *http.Request
 
 // Report back state of rebalance on current node
 func (m *ServiceMgr) getRebalanceStatus(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -479,7 +498,7 @@ func (m *ServiceMgr) getRebalanceStatus(w http.ResponseWriter, r *http.Request)
 
 // Reports aggregated event processing stats from all producers
 func (m *ServiceMgr) getAggEventProcessingStats(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionStats) {
 		return
 	}
 
@@ -505,7 +524,7 @@ func (m *ServiceMgr) getAggEventProcessingStats(w http.ResponseWriter, r *http.R
 
 // Reports aggregated rebalance progress from all Eventing nodes in the cluster
 func (m *ServiceMgr) getAggRebalanceProgress(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -524,7 +543,7 @@ func (m *ServiceMgr) getAggRebalanceProgress(w http.ResponseWriter, r *http.Requ
 
 // Report aggregated rebalance status from all Eventing nodes in the cluster
 func (m *ServiceMgr) getAggRebalanceStatus(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -540,7 +559,7 @@ func (m *ServiceMgr) getAggRebalanceStatus(w http.ResponseWriter, r *http.Reques
 }
 
 func (m *ServiceMgr) getLatencyStats(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionStats) {
 		return
 	}
 
@@ -552,8 +571,7 @@ func (m *ServiceMgr) getLatencyStats(w http.ResponseWriter, r *http.Request) {
 
 		data, err := json.Marshal(lStats)
 		if err != nil {
-			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errMarshalResp.Code))
-			fmt.Fprintf(w, "Failed to unmarshal latency stats, err: %v\n", err)
+			m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errMarshalResp.Code, Info: fmt.Sprintf("Failed to unmarshal latency stats, err: %v", err)})
 			return
 		}
 
@@ -562,12 +580,11 @@ func (m *ServiceMgr) getLatencyStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-	fmt.Fprintf(w, "App: %v not deployed", appName)
+	m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 }
 
 func (m *ServiceMgr) getExecutionStats(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionStats) {
 		return
 	}
 
@@ -579,8 +596,7 @@ func (m *ServiceMgr) getExecutionStats(w http.ResponseWriter, r *http.Request) {
 
 		data, err := json.Marshal(eStats)
 		if err != nil {
-			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errMarshalResp.Code))
-			fmt.Fprintf(w, "Failed to unmarshal execution stats, err: %v\n", err)
+			m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errMarshalResp.Code, Info: fmt.Sprintf("Failed to unmarshal execution stats, err: %v", err)})
 			return
 		}
 
@@ -589,12 +605,11 @@ func (m *ServiceMgr) getExecutionStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-	fmt.Fprintf(w, "App: %v not deployed", appName)
+	m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 }
 
 func (m *ServiceMgr) getFailureStats(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionStats) {
 		return
 	}
 
@@ -606,8 +621,7 @@ func (m *ServiceMgr) getFailureStats(w http.ResponseWriter, r *http.Request) {
 
 		data, err := json.Marshal(fStats)
 		if err != nil {
-			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errMarshalResp.Code))
-			fmt.Fprintf(w, "Failed to unmarshal failure stats, err: %v\n", err)
+			m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errMarshalResp.Code, Info: fmt.Sprintf("Failed to unmarshal failure stats, err: %v", err)})
 			return
 		}
 
@@ -616,12 +630,11 @@ func (m *ServiceMgr) getFailureStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-	fmt.Fprintf(w, "App: %v not deployed", appName)
+	m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 }
 
 func (m *ServiceMgr) getSeqsProcessed(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionStats) {
 		return
 	}
 
@@ -634,17 +647,14 @@ func (m *ServiceMgr) getSeqsProcessed(w http.ResponseWriter, r *http.Request) {
 		data, err := json.Marshal(seqNoProcessed)
 		if err != nil {
 			logging.Errorf("App: %v, failed to fetch vb sequences processed so far, err: %v", appName, err)
-
-			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errGetVbSeqs.Code))
-			fmt.Fprintf(w, "")
+			m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errGetVbSeqs.Code, Info: fmt.Sprintf("Failed to fetch vb sequences processed so far, err: %v", err)})
 			return
 		}
 
 		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
 		fmt.Fprintf(w, "%s", string(data))
 	} else {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-		fmt.Fprintf(w, "App: %v not deployed", appName)
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 	}
 
 }
@@ -657,25 +667,22 @@ func (m *ServiceMgr) setSettingsHandler(w http.ResponseWriter, r *http.Request)
 	params := r.URL.Query()
 	appName := params["name"][0]
 
-	audit.Log(auditevent.SetSettings, r, appName)
 	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errReadReq.Code))
-		w.WriteHeader(m.getDisposition(m.statusCodes.errReadReq.Code))
-		fmt.Fprintf(w, "Failed to read request body, err: %v", err)
+		m.sendPlainErrorStatus(w, &runtimeInfo{Code: m.statusCodes.errReadReq.Code, Info: fmt.Sprintf("Failed to read request body, err: %v", err)})
 		return
 	}
 
 	var settings map[string]interface{}
 	err = json.Unmarshal(data, &settings)
 	if err != nil {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errUnmarshalPld.Code))
-		w.WriteHeader(m.getDisposition(m.statusCodes.errUnmarshalPld.Code))
-		fmt.Fprintf(w, "Failed to unmarshal setting supplied, err: %v", err)
+		m.sendPlainErrorStatus(w, &runtimeInfo{Code: m.statusCodes.errUnmarshalPld.Code, Info: fmt.Sprintf("Failed to unmarshal setting supplied, err: %v", err)})
 		return
 	}
 
-	if info := m.setSettings(appName, data); info.Code != m.statusCodes.ok.Code {
+	m.auditSettingsChange(r, appName, settings)
+
+	if info := m.setSettings(appName, data, m.getAppRevs(appName)); info.Code != m.statusCodes.ok.Code {
 		m.sendErrorInfo(w, info)
 		return
 	}
@@ -726,7 +733,9 @@ func (m *ServiceMgr) getSettings(appName string) (*map[string]interface{}, *runt
 	return &app.Settings, &info
 }
 
-func (m *ServiceMgr) setSettings(appName string, data []byte) (info *runtimeInfo) {
+// Settings are merged into those read from temp store, and stored conditional on the revisions
+// supplied, which must have been read before calling this
+func (m *ServiceMgr) setSettings(appName string, data []byte, revs appRevs) (info *runtimeInfo) {
 	info = &runtimeInfo{}
 	logging.Infof("Set settings for app %v", appName)
 
@@ -783,6 +792,14 @@ func (m *ServiceMgr) setSettings(appName string, data []byte) (info *runtimeInfo
 		return
 	}
 
+	liftPauseIfStopped(app.Settings)
+
+	if _, ok := deployedApps[appName]; deploymentStatus && !ok {
+		if info = m.checkMutationCycles(app); info.Code != m.statusCodes.ok.Code {
+			return
+		}
+	}
+
 	data, err = json.Marshal(app.Settings)
 	if err != nil {
 		info.Code = m.statusCodes.errMarshalResp.Code
@@ -791,7 +808,11 @@ func (m *ServiceMgr) setSettings(appName string, data []byte) (info *runtimeInfo
 	}
 
 	metakvPath := metakvAppSettingsPath + appName
-	err = util.MetakvSet(metakvPath, data, nil)
+	err = util.MetakvSet(metakvPath, data, revs.settings)
+	if err == metakv.ErrRevMismatch {
+		info = m.revMismatchInfo(appName)
+		return
+	}
 	if err != nil {
 		info.Code = m.statusCodes.errSetSettingsPs.Code
 		info.Info = fmt.Sprintf("Failed to store setting for app: %v, err: %v", appName, err)
@@ -809,7 +830,7 @@ func (m *ServiceMgr) setSettings(appName string, data []byte) (info *runtimeInfo
 }
 
 func (m *ServiceMgr) getPrimaryStoreHandler(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -820,72 +841,83 @@ func (m *ServiceMgr) getPrimaryStoreHandler(w http.ResponseWriter, r *http.Reque
 	respData := make([]application, len(appList))
 
 	for index, appName := range appList {
-		data, err := util.ReadAppContent(metakvAppsPath, metakvChecksumPath, appName)
-		if err == nil {
+		if app, info := m.getPrimaryStore(appName); info.Code == m.statusCodes.ok.Code {
+			respData[index] = app
+		}
+	}
 
-			config := cfg.GetRootAsConfig(data, 0)
+	data, err := json.Marshal(respData)
+	if err != nil {
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errMarshalResp.Code, Info: fmt.Sprintf("Failed to marshal response for get_application, err: %v", err)})
+		return
+	}
 
-			app := new(application)
-			app.AppHandlers = string(config.AppCode())
-			app.Name = string(config.AppName())
-			app.ID = int(config.Id())
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s\n", data)
+}
 
-			d := new(cfg.DepCfg)
-			depcfg := new(depCfg)
-			dcfg := config.DepCfg(d)
+// Reads application from primary store along with its settings
+func (m *ServiceMgr) getPrimaryStore(appName string) (app application, info *runtimeInfo) {
+	info = &runtimeInfo{}
+
+	data, err := util.ReadAppContent(metakvAppsPath, metakvChecksumPath, appName)
+	if err != nil {
+		info.Code = m.statusCodes.errGetAppPs.Code
+		info.Info = fmt.Sprintf("Failed to read app: %v from primary store, err: %v", appName, err)
+		return
+	}
 
-			depcfg.MetadataBucket = string(dcfg.MetadataBucket())
-			depcfg.SourceBucket = string(dcfg.SourceBucket())
+	config := cfg.GetRootAsConfig(data, 0)
 
-			var buckets []bucket
-			b := new(cfg.Bucket)
-			for i := 0; i < dcfg.BucketsLength(); i++ {
+	app.AppHandlers = string(config.AppCode())
+	app.Name = string(config.AppName())
+	app.ID = int(config.Id())
 
-				if dcfg.Buckets(b, i) {
-					newBucket := bucket{
-						Alias:      string(b.Alias()),
-						BucketName: string(b.BucketName()),
-					}
-					buckets = append(buckets, newBucket)
-				}
-			}
+	d := new(cfg.DepCfg)
+	depcfg := new(depCfg)
+	dcfg := config.DepCfg(d)
 
-			settingsPath := metakvAppSettingsPath + appName
-			sData, sErr := util.MetakvGet(settingsPath)
-			if sErr == nil {
-				settings := make(map[string]interface{})
-				uErr := json.Unmarshal(sData, &settings)
-				if uErr != nil {
-					logging.Errorf("Failed to unmarshal settings data from metakv, err: %v", uErr)
-				} else {
-					app.Settings = settings
-				}
-			} else {
-				logging.Errorf("Failed to fetch settings data from metakv, err: %v", sErr)
-			}
+	depcfg.MetadataBucket = string(dcfg.MetadataBucket())
+	depcfg.SourceBucket = string(dcfg.SourceBucket())
 
-			depcfg.Buckets = buckets
-			app.DeploymentConfig = *depcfg
+	var buckets []bucket
+	b := new(cfg.Bucket)
+	for i := 0; i < dcfg.BucketsLength(); i++ {
 
-			respData[index] = *app
+		if dcfg.Buckets(b, i) {
+			newBucket := bucket{
+				Alias:      string(b.Alias()),
+				BucketName: string(b.BucketName()),
+			}
+			buckets = append(buckets, newBucket)
 		}
 	}
 
-	data, err := json.Marshal(respData)
-	if err != nil {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errMarshalResp.Code))
-		fmt.Fprintf(w, "Failed to marshal response for get_application, err: %v", err)
-		return
+	settingsPath := metakvAppSettingsPath + appName
+	sData, sErr := util.MetakvGet(settingsPath)
+	if sErr == nil {
+		settings := make(map[string]interface{})
+		uErr := json.Unmarshal(sData, &settings)
+		if uErr != nil {
+			logging.Errorf("Failed to unmarshal settings data from metakv, err: %v", uErr)
+		} else {
+			app.Settings = settings
+		}
+	} else {
+		logging.Errorf("Failed to fetch settings data from metakv, err: %v", sErr)
 	}
 
-	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
-	fmt.Fprintf(w, "%s\n", data)
+	app.Labels = getLabels(appName)
+
+	depcfg.Buckets = buckets
+	app.DeploymentConfig = *depcfg
+
+	info.Code = m.statusCodes.ok.Code
+	return
 }
 
 func (m *ServiceMgr) getTempStoreHandler(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
-		w.WriteHeader(http.StatusUnauthorized)
-		fmt.Fprintln(w, `{"error":"Request not authorized"}`)
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -898,9 +930,7 @@ func (m *ServiceMgr) getTempStoreHandler(w http.ResponseWriter, r *http.Request)
 
 	data, err := json.Marshal(respData)
 	if err != nil {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errMarshalResp.Code))
-		w.WriteHeader(m.getDisposition(m.statusCodes.errMarshalResp.Code))
-		fmt.Fprintf(w, `{"error":"Failed to marshal response for stats, err: %v"}`, err)
+		m.sendPlainErrorStatus(w, &runtimeInfo{Code: m.statusCodes.errMarshalResp.Code, Info: fmt.Sprintf("Failed to marshal response for stats, err: %v", err)})
 		return
 	}
 
@@ -922,6 +952,9 @@ func (m *ServiceMgr) getTempStore(appName string) (app application, info *runtim
 			}
 
 			if app.Name == appName {
+				if labels := getLabels(appName); labels != nil {
+					app.Labels = labels
+				}
 				info.Code = m.statusCodes.ok.Code
 				return
 			}
@@ -947,6 +980,9 @@ func (m *ServiceMgr) getTempStoreAll() []application {
 				continue
 			}
 
+			if labels := getLabels(app.Name); labels != nil {
+				app.Labels = labels
+			}
 			applications[i] = app
 		}
 	}
@@ -966,20 +1002,16 @@ func (m *ServiceMgr) saveTempStoreHandler(w http.ResponseWriter, r *http.Request
 
 	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errReadReq.Code))
-		w.WriteHeader(m.getDisposition(m.statusCodes.errReadReq.Code))
-		fmt.Fprintf(w, "Failed to read request body, err: %v", err)
+		m.sendPlainErrorStatus(w, &runtimeInfo{Code: m.statusCodes.errReadReq.Code, Info: fmt.Sprintf("Failed to read request body, err: %v", err)})
 		return
 	}
 
 	var app application
 	err = json.Unmarshal(data, &app)
 	if err != nil {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errUnmarshalPld.Code))
-		w.WriteHeader(m.getDisposition(m.statusCodes.errUnmarshalPld.Code))
 		errString := fmt.Sprintf("App: %s, Failed to unmarshal payload", appName)
 		logging.Errorf("%s, err: %v", errString, err)
-		fmt.Fprintf(w, "%s\n", errString)
+		m.sendPlainErrorStatus(w, &runtimeInfo{Code: m.statusCodes.errUnmarshalPld.Code, Info: errString})
 		return
 	}
 
@@ -1040,6 +1072,11 @@ func (m *ServiceMgr) saveTempStore(app application) (info *runtimeInfo) {
 		return
 	}
 
+	// Labels key is shared with primary store, so a draft save keeps it current as well
+	if info = m.saveLabels(appName, app.Labels, nil); info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
 	//Delete stale entry
 	err = util.DeleteAppContent(metakvTempAppsPath, metakvTempChecksumPath, appName)
 	if err != nil {
@@ -1074,8 +1111,7 @@ func (m *ServiceMgr) savePrimaryStoreHandler(w http.ResponseWriter, r *http.Requ
 	if err != nil {
 		errString := fmt.Sprintf("App: %s, failed to read content from http request body", appName)
 		logging.Errorf("%s, err: %v", errString, err)
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errReadReq.Code))
-		fmt.Fprintf(w, "%s\n", errString)
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errReadReq.Code, Info: errString})
 		return
 	}
 
@@ -1084,8 +1120,7 @@ func (m *ServiceMgr) savePrimaryStoreHandler(w http.ResponseWriter, r *http.Requ
 	if err != nil {
 		errString := fmt.Sprintf("App: %s, Failed to unmarshal payload", appName)
 		logging.Errorf("%s, err: %v", errString, err)
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errUnmarshalPld.Code))
-		fmt.Fprintf(w, "%s\n", errString)
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errUnmarshalPld.Code, Info: errString})
 		return
 	}
 
@@ -1094,7 +1129,10 @@ func (m *ServiceMgr) savePrimaryStoreHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	info := m.savePrimaryStore(app)
+	info := m.savePrimaryStore(app, m.getAppRevs(appName))
+	if info.Code == m.statusCodes.ok.Code {
+		m.recordRevision(app, getAuthor(r), 0)
+	}
 	m.sendErrorInfo(w, info)
 }
 
@@ -1126,8 +1164,10 @@ func (m *ServiceMgr) checkRebalanceStatus() (info *runtimeInfo) {
 	return
 }
 
-// Saves application to metakv and returns appropriate success/error code
-func (m *ServiceMgr) savePrimaryStore(app application) (info *runtimeInfo) {
+// Saves application to metakv and returns appropriate success/error code. Settings and
+// labels are stored conditional on the revisions supplied, and settings are stored first
+// so that nothing is written if the function was changed since.
+func (m *ServiceMgr) savePrimaryStore(app application, revs appRevs) (info *runtimeInfo) {
 	appName := app.Name
 	info = &runtimeInfo{}
 	logging.Infof("Saving application %v to primary store", appName)
@@ -1150,39 +1190,101 @@ func (m *ServiceMgr) savePrimaryStore(app application) (info *runtimeInfo) {
 		return
 	}
 
-	nsServerEndpoint := net.JoinHostPort(util.Localhost(), m.restPort)
-	cinfo, err := util.FetchNewClusterInfoCache(nsServerEndpoint)
-	if err != nil {
-		info.Code = m.statusCodes.errConnectNsServer.Code
-		info.Info = fmt.Sprintf("Failed to initialise cluster info cache, err: %v", err)
+	if info = m.validateBuckets(&app.DeploymentConfig); info.Code != m.statusCodes.ok.Code {
 		return
 	}
 
-	if cinfo.GetBucketUUID(app.DeploymentConfig.SourceBucket) == "" {
-		info.Code = m.statusCodes.errSrcBucketMissing.Code
-		info.Info = fmt.Sprintf("Supplied source bucket: %v doesn't exist", app.DeploymentConfig.SourceBucket)
+	if deploymentStatus, _ := app.Settings["deployment_status"].(bool); deploymentStatus {
+		if info = m.checkMutationCycles(app); info.Code != m.statusCodes.ok.Code {
+			return
+		}
+	}
+
+	appContent, info := m.compileApp(app)
+	if info.Code != m.statusCodes.ok.Code {
 		return
 	}
 
-	if cinfo.GetBucketUUID(app.DeploymentConfig.MetadataBucket) == "" {
-		info.Code = m.statusCodes.errMetaBucketMissing.Code
-		info.Info = fmt.Sprintf("Supplied metadata bucket: %v doesn't exist", app.DeploymentConfig.MetadataBucket)
+	settingsPath := metakvAppSettingsPath + appName
+	settings := app.Settings
+
+	mData, mErr := json.Marshal(&settings)
+	if mErr != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("App: %s Failed to marshal settings, err: %v", appName, mErr)
 		return
 	}
 
-	isMemcached, err := cinfo.IsMemcached(app.DeploymentConfig.SourceBucket)
+	mkvErr := util.MetakvSet(settingsPath, mData, revs.settings)
+	if mkvErr == metakv.ErrRevMismatch {
+		info = m.revMismatchInfo(appName)
+		return
+	}
+	if mkvErr != nil {
+		info.Code = m.statusCodes.errSetSettingsPs.Code
+		info.Info = fmt.Sprintf("App: %s Failed to store updated settings in metakv, err: %v", appName, mkvErr)
+		return
+	}
+
+	if info = m.saveLabels(appName, app.Labels, revs.labels); info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
+	//Delete stale entry
+	err := util.DeleteAppContent(metakvAppsPath, metakvChecksumPath, appName)
 	if err != nil {
-		info.Code = m.statusCodes.errBucketTypeCheck.Code
-		info.Info = fmt.Sprintf("Failed to check bucket type using cluster info cache, err: %v", err)
+		info.Code = m.statusCodes.errSaveAppPs.Code
+		info.Info = fmt.Sprintf("Failed to clean up stale entry for app: %v err: %v", appName, err)
 		return
 	}
 
-	if isMemcached {
-		info.Code = m.statusCodes.errMemcachedBucket.Code
-		info.Info = "Source bucket is memcached, should be either couchbase or ephemeral"
+	err = util.WriteAppContent(metakvAppsPath, metakvChecksumPath, appName, appContent)
+	if err != nil {
+		info.Code = m.statusCodes.errSaveAppPs.Code
+		info.Info = fmt.Sprintf("App: %v failed to write to metakv, err: %v", appName, err)
 		return
 	}
 
+	info.Code = m.statusCodes.ok.Code
+	info.Info = "Stored application config in metakv"
+	return
+}
+
+// Checks size of the handler and compiles it, returns app content to be stored in primary store
+func (m *ServiceMgr) compileApp(app application) (appContent []byte, info *runtimeInfo) {
+	appName := app.Name
+	info = &runtimeInfo{}
+
+	appContent = m.encodeAppContent(app)
+
+	if len(appContent) > maxHandlerSize {
+		info.Code = m.statusCodes.errAppCodeSize.Code
+		info.Info = fmt.Sprintf("App: %s Handler Code size is more than 128K", appName)
+		info.Field = "appcode"
+		return
+	}
+
+	c := &consumer.Consumer{}
+	compilationInfo, err := c.SpawnCompilationWorker(app.AppHandlers, string(appContent), appName, m.adminHTTPPort)
+	if err != nil || !compilationInfo.CompileSuccess {
+		res, mErr := json.Marshal(&compilationInfo)
+		if mErr != nil {
+			info.Code = m.statusCodes.errMarshalResp.Code
+			info.Info = fmt.Sprintf("App: %s Failed to marshal compilation status, err: %v", appName, mErr)
+			return
+		}
+
+		info.Code = m.statusCodes.errHandlerCompile.Code
+		info.Info = fmt.Sprintf("%v\n", string(res))
+		return
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+// Encodes application in the flatbuffer format stored in primary store
+func (m *ServiceMgr) encodeAppContent(app application) []byte {
 	builder := flatbuffers.NewBuilder(0)
 
 	var bNames []flatbuffers.UOffsetT
@@ -1226,68 +1328,11 @@ func (m *ServiceMgr) savePrimaryStore(app application) (info *runtimeInfo) {
 
 	builder.Finish(config)
 
-	appContent := builder.FinishedBytes()
-
-	if len(appContent) > maxHandlerSize {
-		info.Code = m.statusCodes.errAppCodeSize.Code
-		info.Info = fmt.Sprintf("App: %s Handler Code size is more than 128K", appName)
-		return
-	}
-
-	c := &consumer.Consumer{}
-	compilationInfo, err := c.SpawnCompilationWorker(app.AppHandlers, string(appContent), appName, m.adminHTTPPort)
-	if err != nil || !compilationInfo.CompileSuccess {
-		res, mErr := json.Marshal(&compilationInfo)
-		if mErr != nil {
-			info.Code = m.statusCodes.errMarshalResp.Code
-			info.Info = fmt.Sprintf("App: %s Failed to marshal compilation status, err: %v", appName, mErr)
-			return
-		}
-
-		info.Code = m.statusCodes.errHandlerCompile.Code
-		info.Info = fmt.Sprintf("%v\n", string(res))
-		return
-	}
-
-	settingsPath := metakvAppSettingsPath + appName
-	settings := app.Settings
-
-	mData, mErr := json.Marshal(&settings)
-	if mErr != nil {
-		info.Code = m.statusCodes.errMarshalResp.Code
-		info.Info = fmt.Sprintf("App: %s Failed to marshal settings, err: %v", appName, mErr)
-		return
-	}
-
-	mkvErr := util.MetakvSet(settingsPath, mData, nil)
-	if mkvErr != nil {
-		info.Code = m.statusCodes.errSetSettingsPs.Code
-		info.Info = fmt.Sprintf("App: %s Failed to store updated settings in metakv, err: %v", appName, mkvErr)
-		return
-	}
-
-	//Delete stale entry
-	err = util.DeleteAppContent(metakvAppsPath, metakvChecksumPath, appName)
-	if err != nil {
-		info.Code = m.statusCodes.errSaveAppPs.Code
-		info.Info = fmt.Sprintf("Failed to clean up stale entry for app: %v err: %v", appName, err)
-		return
-	}
-
-	err = util.WriteAppContent(metakvAppsPath, metakvChecksumPath, appName, appContent)
-	if err != nil {
-		info.Code = m.statusCodes.errSaveAppPs.Code
-		info.Info = fmt.Sprintf("App: %v failed to write to metakv, err: %v", appName, err)
-		return
-	}
-
-	info.Code = m.statusCodes.ok.Code
-	info.Info = "Stored application config in metakv"
-	return
+	return builder.FinishedBytes()
 }
 
 func (m *ServiceMgr) getErrCodes(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -1295,7 +1340,7 @@ func (m *ServiceMgr) getErrCodes(w http.ResponseWriter, r *http.Request) {
 }
 
 func (m *ServiceMgr) getDcpEventsRemaining(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionStats) {
 		return
 	}
 
@@ -1310,12 +1355,11 @@ func (m *ServiceMgr) getDcpEventsRemaining(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-	fmt.Fprintf(w, "App: %v not deployed", appName)
+	m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 }
 
 func (m *ServiceMgr) getAggBootstrappingApps(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -1331,7 +1375,7 @@ func (m *ServiceMgr) getAggBootstrappingApps(w http.ResponseWriter, r *http.Requ
 }
 
 func (m *ServiceMgr) getBootstrappingApps(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -1346,7 +1390,7 @@ func (m *ServiceMgr) getBootstrappingApps(w http.ResponseWriter, r *http.Request
 }
 
 func (m *ServiceMgr) getEventingConsumerPids(w http.ResponseWriter, r *http.Request) {
-	if !m.validateAuth(w, r, EventingPermissionManage) {
+	if !m.validateAuth(w, r, EventingPermissionRead) {
 		return
 	}
 
@@ -1358,8 +1402,7 @@ func (m *ServiceMgr) getEventingConsumerPids(w http.ResponseWriter, r *http.Requ
 
 		data, err := json.Marshal(&workerPidMapping)
 		if err != nil {
-			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errMarshalResp.Code))
-			fmt.Fprintf(w, "Failed to marshal consumer pids, err: %v", err)
+			m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errMarshalResp.Code, Info: fmt.Sprintf("Failed to marshal consumer pids, err: %v", err)})
 			return
 		}
 
@@ -1368,8 +1411,7 @@ func (m *ServiceMgr) getEventingConsumerPids(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errAppNotDeployed.Code))
-	fmt.Fprintf(w, "App: %v not deployed", appName)
+	m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errAppNotDeployed.Code, Info: fmt.Sprintf("App: %v not deployed", appName)})
 }
 
 func (m *ServiceMgr) getCreds(w http.ResponseWriter, r *http.Request) {
@@ -1381,16 +1423,17 @@ func (m *ServiceMgr) getCreds(w http.ResponseWriter, r *http.Request) {
 
 	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errReadReq.Code))
-		fmt.Fprintf(w, "Failed to read request body, err: %v", err)
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errReadReq.Code, Info: fmt.Sprintf("Failed to read request body, err: %v", err)})
 		return
 	}
 
 	strippedEndpoint := util.StripScheme(string(data))
+	audit.Log(auditevent.CredentialsFetched, r, audit.Context{Endpoint: strippedEndpoint})
+
 	username, password, err := cbauth.GetMemcachedServiceAuth(strippedEndpoint)
 	if err != nil {
 		logging.Errorf("Failed to get credentials for endpoint: %rs, err: %v", strippedEndpoint, err)
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errRbacCreds.Code))
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errRbacCreds.Code, Info: fmt.Sprintf("Failed to get credentials for endpoint: %s", strippedEndpoint)})
 	} else {
 		response := url.Values{}
 		response.Add("username", username)
@@ -1407,6 +1450,7 @@ func (m *ServiceMgr) clearEventStats(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logging.Infof("Got request to clear event stats from host: %rs", r.Host)
+	audit.Log(auditevent.EventStatsCleared, r, audit.Context{})
 	m.superSup.ClearEventStats()
 }
 
@@ -1419,7 +1463,7 @@ func (m *ServiceMgr) parseQueryHandler(w http.ResponseWriter, r *http.Request) {
 
 	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errReadReq.Code))
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errReadReq.Code, Info: fmt.Sprintf("Failed to read request body, err: %v", err)})
 		return
 	}
 
@@ -1432,6 +1476,35 @@ func (m *ServiceMgr) parseQueryHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "%s", response.Encode())
 }
 
+// Config failing to load is audited as having had no prior value
+func (m *ServiceMgr) auditConfigChange(r *http.Request, newConfig config) {
+	var oldConfig interface{}
+	if c, info := m.getConfig(); info.Code == m.statusCodes.ok.Code {
+		oldConfig = c
+	}
+	audit.Log(auditevent.ConfigChanged, r, audit.Context{OldValue: oldConfig, NewValue: newConfig})
+}
+
+// Audits settings of appName being changed, along with the values they're changed from
+func (m *ServiceMgr) auditSettingsChange(r *http.Request, appName string, settings map[string]interface{}) {
+	var current map[string]interface{}
+	if app, info := m.getTempStore(appName); info.Code == m.statusCodes.ok.Code {
+		current = app.Settings
+	}
+	audit.Log(auditevent.SettingsChanged, r, settingsChangeContext(appName, current, settings))
+}
+
+// Old value only carries settings being changed, those which aren't set yet are left out
+func settingsChangeContext(appName string, current, settings map[string]interface{}) audit.Context {
+	oldValue := make(map[string]interface{}, len(settings))
+	for setting := range settings {
+		if val, ok := current[setting]; ok {
+			oldValue[setting] = val
+		}
+	}
+	return audit.Context{Function: appName, OldValue: oldValue, NewValue: settings}
+}
+
 func (m *ServiceMgr) getConfig() (c config, info *runtimeInfo) {
 	info = &runtimeInfo{}
 	data, err := util.MetakvGet(metakvConfigPath)
@@ -1472,14 +1545,15 @@ func (m *ServiceMgr) saveConfig(c config) (info *runtimeInfo) {
 		return
 	}
 
+	// Applied on this node right away, others pick it up from metakv
+	m.setErrorFormat(data)
 	info.Code = m.statusCodes.ok.Code
 	return
 }
 
 func (m *ServiceMgr) configHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	if !m.validateAuth(w, r, EventingPermissionManage) {
-		fmt.Fprintln(w, `{"error":"Request not authorized"}`)
+	if !m.validateAuth(w, r, methodPermission(r)) {
 		return
 	}
 
@@ -1506,8 +1580,6 @@ func (m *ServiceMgr) configHandler(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "%s", string(response))
 
 	case "POST":
-		audit.Log(auditevent.SaveConfig, r, nil)
-
 		data, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			info.Code = m.statusCodes.errReadReq.Code
@@ -1525,8 +1597,19 @@ func (m *ServiceMgr) configHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if c.ErrorFormat != "" && c.ErrorFormat != errorFormatV1 && c.ErrorFormat != errorFormatV2 {
+			info.Code = m.statusCodes.errInvalidConfig.Code
+			info.Info = fmt.Sprintf("Invalid value for error_format, possible values are %s, %s", errorFormatV1, errorFormatV2)
+			info.Field = "error_format"
+			m.sendErrorInfo(w, info)
+			return
+		}
+
+		m.auditConfigChange(r, c)
+
 		if info = m.saveConfig(c); info.Code != m.statusCodes.ok.Code {
 			m.sendErrorInfo(w, info)
+			return
 		}
 
 		response := configResponse{false}
@@ -1541,32 +1624,92 @@ func (m *ServiceMgr) configHandler(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "%s", string(data))
 
 	default:
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		m.sendMethodNotAllowed(w, r)
 		return
 	}
 }
 
+var (
+	functions                     = regexp.MustCompile("^/api/v1/functions/?$")
+	functionsGraph                = regexp.MustCompile("^/api/v1/functions/graph/?$")
+	functionsSettings             = regexp.MustCompile("^/api/v1/functions/settings/?$")
+	functionsName                 = regexp.MustCompile("^/api/v1/functions/(.+[^/])/?$") // Match is agnostic of trailing '/'
+	functionsNameSettings         = regexp.MustCompile("^/api/v1/functions/(.+[^/])/settings/?$")
+	functionsNameRevisions        = regexp.MustCompile("^/api/v1/functions/(.+[^/])/revisions/?$")
+	functionsNameRevisionsDiff    = regexp.MustCompile("^/api/v1/functions/(.+[^/])/revisions/diff/?$")
+	functionsNameRevision         = regexp.MustCompile("^/api/v1/functions/(.+[^/])/revisions/([0-9]+)/?$")
+	functionsNameRevisionRollback = regexp.MustCompile("^/api/v1/functions/(.+[^/])/revisions/([0-9]+)/rollback/?$")
+	functionsNamePause            = regexp.MustCompile("^/api/v1/functions/(.+[^/])/pause/?$")
+	functionsNameResume           = regexp.MustCompile("^/api/v1/functions/(.+[^/])/resume/?$")
+	functionsNameValidate         = regexp.MustCompile("^/api/v1/functions/(.+[^/])/validate/?$")
+	functionsNameLogs             = regexp.MustCompile("^/api/v1/functions/(.+[^/])/logs/?$")
+	functionsNameDiff             = regexp.MustCompile("^/api/v1/functions/(.+[^/])/diff/?$")
+	functionsNameN1QL             = regexp.MustCompile("^/api/v1/functions/(.+[^/])/n1ql/?$")
+)
+
 func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	if !m.validateAuth(w, r, EventingPermissionManage) {
-		fmt.Fprintln(w, `{"error":"Request not authorized"}`)
-		return
-	}
 
-	functions := regexp.MustCompile("^/api/v1/functions/?$")
-	functionsName := regexp.MustCompile("^/api/v1/functions/(.+[^/])/?$") // Match is agnostic of trailing '/'
-	functionsNameSettings := regexp.MustCompile("^/api/v1/functions/(.+[^/])/settings/?$")
 	info := &runtimeInfo{}
 
-	if match := functionsNameSettings.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+	if functionsGraph.MatchString(r.URL.Path) {
+		m.graphHandler(w, r)
+	} else if functionsSettings.MatchString(r.URL.Path) {
+		m.bulkSettingsHandler(w, r)
+	} else if match := functionsNameRevisionRollback.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if m.validateAuth(w, r, EventingPermissionManage) {
+			m.rollbackHandler(w, r, match[1], match[2])
+		}
+	} else if match := functionsNamePause.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if m.validateAuth(w, r, EventingPermissionManage) {
+			m.pauseResumeHandler(w, r, match[1], true)
+		}
+	} else if match := functionsNameResume.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if m.validateAuth(w, r, EventingPermissionManage) {
+			m.pauseResumeHandler(w, r, match[1], false)
+		}
+	} else if match := functionsNameValidate.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		// Validation spawns a compiler, hence isn't open to readers
+		if m.validateAuth(w, r, EventingPermissionManage) {
+			m.validateHandler(w, r, match[1])
+		}
+	} else if match := functionsNameLogs.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if m.validateAuth(w, r, EventingPermissionRead) {
+			m.appLogHandler(w, r, match[1])
+		}
+	} else if match := functionsNameRevisionsDiff.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if m.validateAuth(w, r, methodPermission(r)) {
+			m.revisionsDiffHandler(w, r, match[1])
+		}
+	} else if match := functionsNameRevision.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if m.validateAuth(w, r, methodPermission(r)) {
+			m.revisionHandler(w, r, match[1], match[2])
+		}
+	} else if match := functionsNameRevisions.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if m.validateAuth(w, r, methodPermission(r)) {
+			m.revisionsHandler(w, r, match[1])
+		}
+	} else if match := functionsNameDiff.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if m.validateAuth(w, r, EventingPermissionRead) {
+			m.draftDiffHandler(w, r, match[1])
+		}
+	} else if match := functionsNameN1QL.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if m.validateAuth(w, r, EventingPermissionRead) {
+			m.n1qlReportHandler(w, r, match[1])
+		}
+	} else if match := functionsNameSettings.FindStringSubmatch(r.URL.Path); len(match) != 0 {
 		info = &runtimeInfo{}
 		appName := match[1]
 		switch r.Method {
 		case "GET":
+			if !m.validateAuth(w, r, EventingPermissionRead) {
+				return
+			}
+
 			audit.Log(auditevent.GetSettings, r, nil)
+			revs := m.getAppRevs(appName)
 			settings, info := m.getSettings(appName)
 			if info.Code != m.statusCodes.ok.Code {
-				w.WriteHeader(http.StatusNotFound)
 				m.sendErrorInfo(w, info)
 				return
 			}
@@ -1579,11 +1722,14 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
+			w.Header().Set("ETag", revs.settingsETag())
 			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
 			fmt.Fprintf(w, "%s", string(response))
 
 		case "POST":
-			audit.Log(auditevent.SetSettings, r, appName)
+			if !m.validateAuth(w, r, EventingPermissionManage) {
+				return
+			}
 
 			data, err := ioutil.ReadAll(r.Body)
 			if err != nil {
@@ -1602,25 +1748,38 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
+			m.auditSettingsChange(r, appName, settings)
+
 			if info = m.validateSettings(settings); info.Code != m.statusCodes.ok.Code {
 				m.sendErrorInfo(w, info)
 				return
 			}
 
-			if info = m.setSettings(appName, data); info.Code != m.statusCodes.ok.Code {
+			revs := m.getAppRevs(appName)
+			if info = m.checkIfMatch(r, revs.settingsETag()); info.Code != m.statusCodes.ok.Code {
+				m.sendErrorInfo(w, info)
+				return
+			}
+
+			if info = m.setSettings(appName, data, revs); info.Code != m.statusCodes.ok.Code {
 				m.sendErrorInfo(w, info)
 				return
 			}
 		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			m.sendMethodNotAllowed(w, r)
 			return
 		}
 	} else if match := functionsName.FindStringSubmatch(r.URL.Path); len(match) != 0 {
 		appName := match[1]
+		if !m.validateAuth(w, r, methodPermission(r)) {
+			return
+		}
+
 		switch r.Method {
 		case "GET":
 			audit.Log(auditevent.FetchDrafts, r, appName)
 
+			revs := m.getAppRevs(appName)
 			app, info := m.getTempStore(appName)
 			if info.Code != m.statusCodes.ok.Code {
 				m.sendErrorInfo(w, info)
@@ -1635,6 +1794,7 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
+			w.Header().Set("ETag", revs.appETag())
 			w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
 			fmt.Fprintf(w, "%s", string(response))
 
@@ -1656,18 +1816,27 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 			if app.Name != appName {
 				info.Code = m.statusCodes.errAppNameMismatch.Code
 				info.Info = fmt.Sprintf("Function name in the URL (%s) and body (%s) must be same", appName, app.Name)
+				info.Field = "appname"
+				m.sendErrorInfo(w, info)
+				return
+			}
+
+			revs := m.getAppRevs(appName)
+			if info = m.checkIfMatch(r, revs.appETag()); info.Code != m.statusCodes.ok.Code {
 				m.sendErrorInfo(w, info)
 				return
 			}
 
 			// Save to temp store only if saving to primary store succeeds
-			if runtimeInfo := m.savePrimaryStore(app); runtimeInfo.Code == m.statusCodes.ok.Code {
+			if runtimeInfo := m.savePrimaryStore(app, revs); runtimeInfo.Code == m.statusCodes.ok.Code {
 				audit.Log(auditevent.SaveDraft, r, appName)
 
 				if runtimeInfo := m.saveTempStore(app); runtimeInfo.Code != m.statusCodes.ok.Code {
 					m.sendErrorInfo(w, runtimeInfo)
 					return
 				}
+
+				m.recordRevision(app, getAuthor(r), 0)
 			} else {
 				m.sendErrorInfo(w, runtimeInfo)
 			}
@@ -1675,7 +1844,13 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 		case "DELETE":
 			audit.Log(auditevent.DeleteFunction, r, appName)
 
-			info := m.deletePrimaryStore(appName)
+			revs := m.getAppRevs(appName)
+			if info := m.checkIfMatch(r, revs.appETag()); info.Code != m.statusCodes.ok.Code {
+				m.sendErrorInfo(w, info)
+				return
+			}
+
+			info := m.deletePrimaryStore(appName, revs)
 			// Delete the application from temp store only if app does not exist in primary store
 			// or if the deletion succeeds on primary store
 			if info.Code == m.statusCodes.errAppNotDeployed.Code || info.Code == m.statusCodes.ok.Code {
@@ -1690,14 +1865,18 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 			}
 
 		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			m.sendMethodNotAllowed(w, r)
 			return
 		}
 
 	} else if match := functions.FindStringSubmatch(r.URL.Path); len(match) != 0 {
+		if !m.validateAuth(w, r, methodPermission(r)) {
+			return
+		}
+
 		switch r.Method {
 		case "GET":
-			m.getTempStoreHandler(w, r)
+			m.functionListHandler(w, r)
 
 		case "POST":
 			infoList := []*runtimeInfo{}
@@ -1711,10 +1890,13 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 				}
 
 				// Save to temp store only if saving to primary store succeeds
-				if info := m.savePrimaryStore(app); info.Code == m.statusCodes.ok.Code {
+				if info := m.savePrimaryStore(app, m.getAppRevs(app.Name)); info.Code == m.statusCodes.ok.Code {
 					audit.Log(auditevent.SaveDraft, r, app.Name)
 
 					info := m.saveTempStore(app)
+					if info.Code == m.statusCodes.ok.Code {
+						m.recordRevision(app, getAuthor(r), 0)
+					}
 					infoList = append(infoList, info)
 				} else {
 					infoList = append(infoList, info)
@@ -1728,7 +1910,7 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 			for _, app := range m.getTempStoreAll() {
 				audit.Log(auditevent.DeleteFunction, r, app.Name)
 
-				info := m.deletePrimaryStore(app.Name)
+				info := m.deletePrimaryStore(app.Name, m.getAppRevs(app.Name))
 				// Delete the application from temp store only if app does not exist in primary store
 				// or if the deletion succeeds on primary store
 				if info.Code == m.statusCodes.errAppNotDeployed.Code || info.Code == m.statusCodes.ok.Code {
@@ -1744,7 +1926,7 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 			m.sendRuntimeInfoList(w, infoList)
 
 		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			m.sendMethodNotAllowed(w, r)
 			return
 		}
 	}
@@ -1752,8 +1934,7 @@ func (m *ServiceMgr) functionsHandler(w http.ResponseWriter, r *http.Request) {
 
 func (m *ServiceMgr) statsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	if !m.validateAuth(w, r, EventingPermissionManage) {
-		fmt.Fprintln(w, `{"error":"Request not authorized"}`)
+	if !m.validateAuth(w, r, EventingPermissionStats) {
 		return
 	}
 
@@ -1774,6 +1955,7 @@ func (m *ServiceMgr) statsHandler(w http.ResponseWriter, r *http.Request) {
 				stats.FailureStats = m.superSup.GetFailureStats(app.Name)
 				stats.FunctionName = app.Name
 				stats.InternalVbDistributionStats = m.superSup.InternalVbDistributionStats(app.Name)
+				stats.Labels = app.Labels
 				stats.LcbExceptionStats = m.superSup.GetLcbExceptionsStats(app.Name)
 				stats.WorkerPids = m.superSup.GetEventingConsumerPids(app.Name)
 				stats.PlannerStats = m.superSup.PlannerStats(app.Name)
@@ -1801,14 +1983,16 @@ func (m *ServiceMgr) statsHandler(w http.ResponseWriter, r *http.Request) {
 
 		response, err := json.Marshal(statsList)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, `{"error":"Failed to marshal response for stats, err: %v"}`, err)
+			info := &runtimeInfo{}
+			info.Code = m.statusCodes.errMarshalResp.Code
+			info.Info = fmt.Sprintf("Failed to marshal response for stats, err: %v", err)
+			m.sendErrorInfo(w, info)
 			return
 		}
 
 		fmt.Fprintf(w, "%s", string(response))
 	} else {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		m.sendMethodNotAllowed(w, r)
 	}
 
 	return
@@ -1817,15 +2001,21 @@ func (m *ServiceMgr) statsHandler(w http.ResponseWriter, r *http.Request) {
 // Clears up all Eventing related artifacts from metakv, typically will be used for rebalance tests
 func (m *ServiceMgr) cleanupEventing(w http.ResponseWriter, r *http.Request) {
 	if !m.validateAuth(w, r, EventingPermissionManage) {
-		fmt.Fprintln(w, `{"error":"Request not authorized"}`)
 		return
 	}
 
-	audit.Log(auditevent.CleanupEventing, r, nil)
+	functions := make([]string, 0)
+	for _, app := range m.getTempStoreAll() {
+		functions = append(functions, app.Name)
+	}
+	audit.Log(auditevent.EventingCleanedUp, r, audit.Context{OldValue: map[string]interface{}{"functions": functions}})
 
 	util.Retry(util.NewFixedBackoff(time.Second), cleanupEventingMetaKvPath, metakvChecksumPath)
 	util.Retry(util.NewFixedBackoff(time.Second), cleanupEventingMetaKvPath, metakvTempChecksumPath)
 	util.Retry(util.NewFixedBackoff(time.Second), cleanupEventingMetaKvPath, metakvAppsPath)
 	util.Retry(util.NewFixedBackoff(time.Second), cleanupEventingMetaKvPath, metakvTempAppsPath)
 	util.Retry(util.NewFixedBackoff(time.Second), cleanupEventingMetaKvPath, metakvAppSettingsPath)
+	util.Retry(util.NewFixedBackoff(time.Second), cleanupEventingMetaKvPath, metakvAppLabelsPath)
+	util.Retry(util.NewFixedBackoff(time.Second), cleanupEventingMetaKvPath, metakvAppRevisionsPath)
+	util.Retry(util.NewFixedBackoff(time.Second), cleanupEventingMetaKvPath, metakvRevisionChecksumPath)
 }
@@ -0,0 +1,42 @@
+package servicemanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSettingsChangeContext(t *testing.T) {
+	current := map[string]interface{}{
+		"deployment_status": true,
+		"worker_count":      float64(3),
+	}
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		want     map[string]interface{}
+	}{
+		{"changed", map[string]interface{}{"worker_count": float64(4)}, map[string]interface{}{"worker_count": float64(3)}},
+		{"not set yet", map[string]interface{}{"log_level": "TRACE"}, map[string]interface{}{}},
+		{"lifecycle", map[string]interface{}{"deployment_status": false, "processing_status": false},
+			map[string]interface{}{"deployment_status": true}},
+	}
+
+	for _, test := range tests {
+		got := settingsChangeContext("f1", current, test.settings)
+
+		if got.Function != "f1" {
+			t.Errorf("%s: got function %s want f1", test.name, got.Function)
+		}
+		if !reflect.DeepEqual(got.OldValue, test.want) {
+			t.Errorf("%s: got old value %v want %v", test.name, got.OldValue, test.want)
+		}
+		if !reflect.DeepEqual(got.NewValue, test.settings) {
+			t.Errorf("%s: got new value %v want %v", test.name, got.NewValue, test.settings)
+		}
+	}
+
+	if got := settingsChangeContext("f1", nil, map[string]interface{}{"worker_count": float64(4)}); !reflect.DeepEqual(got.OldValue, map[string]interface{}{}) {
+		t.Errorf("no current settings: got old value %v want empty", got.OldValue)
+	}
+}
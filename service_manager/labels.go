@@ -0,0 +1,109 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/couchbase/cbauth/metakv"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+// Labels are filtered on as key:value, hence keys can't carry ':'
+var labelKeyRegex = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_.-]*$")
+
+func (m *ServiceMgr) validateLabels(labels map[string]string) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+	info.Code = m.statusCodes.errInvalidConfig.Code
+
+	if len(labels) > maxLabels {
+		info.Info = fmt.Sprintf("Function can't have more than %d labels", maxLabels)
+		info.Field = "labels"
+		return
+	}
+
+	for key, value := range labels {
+		if len(key) > maxLabelLength || !labelKeyRegex.MatchString(key) {
+			info.Info = fmt.Sprintf("Invalid label key: %s, it must start with an alphanumeric, followed by alphanumerics, '_', '.' or '-', and be at most %d characters long",
+				key, maxLabelLength)
+			info.Field = "labels." + key
+			return
+		}
+
+		if len(value) > maxLabelLength {
+			info.Info = fmt.Sprintf("Value of label: %s must be at most %d characters long", key, maxLabelLength)
+			info.Field = "labels." + key
+			return
+		}
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+// Label selector is either key:value, or just key to match any value
+func matchesLabel(labels map[string]string, selector string) bool {
+	parts := strings.SplitN(selector, ":", 2)
+
+	value, ok := labels[parts[0]]
+	if !ok {
+		return false
+	}
+
+	return len(parts) == 1 || value == parts[1]
+}
+
+func matchesLabels(labels map[string]string, selectors []string) bool {
+	for _, selector := range selectors {
+		if !matchesLabel(labels, selector) {
+			return false
+		}
+	}
+	return true
+}
+
+// Labels aren't part of the flatbuffer encoded app content, so they are kept along
+// side it in primary store. Write is conditional on rev unless it's nil.
+func (m *ServiceMgr) saveLabels(appName string, labels map[string]string, rev interface{}) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+
+	data, err := json.Marshal(labels)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("App: %s Failed to marshal labels, err: %v", appName, err)
+		return
+	}
+
+	err = util.MetakvSet(metakvAppLabelsPath+appName, data, rev)
+	if err == metakv.ErrRevMismatch {
+		info = m.revMismatchInfo(appName)
+		return
+	}
+	if err != nil {
+		info.Code = m.statusCodes.errSaveAppPs.Code
+		info.Info = fmt.Sprintf("App: %s Failed to store labels in metakv, err: %v", appName, err)
+		return
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+// Functions stored before labels were introduced don't have any
+func getLabels(appName string) map[string]string {
+	data, err := util.MetakvGet(metakvAppLabelsPath + appName)
+	if err != nil || data == nil {
+		return nil
+	}
+
+	var labels map[string]string
+	err = json.Unmarshal(data, &labels)
+	if err != nil {
+		logging.Errorf("Failed to unmarshal labels of app: %s from metakv, err: %v", appName, err)
+		return nil
+	}
+
+	return labels
+}
@@ -0,0 +1,71 @@
+package servicemanager
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLabels(t *testing.T) {
+	m := &ServiceMgr{}
+	m.initErrCodes()
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= maxLabels; i++ {
+		tooMany[strings.Repeat("k", i+1)] = ""
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		field  string
+		valid  bool
+	}{
+		{"none", nil, "", true},
+		{"key and value", map[string]string{"team": "orders", "tier.1_a-b": ""}, "", true},
+		{"longest key and value", map[string]string{strings.Repeat("k", maxLabelLength): strings.Repeat("v", maxLabelLength)}, "", true},
+		{"too many", tooMany, "labels", false},
+		{"key with colon", map[string]string{"team:x": "orders"}, "labels.team:x", false},
+		{"key starting with punctuation", map[string]string{"_team": "orders"}, "labels._team", false},
+		{"empty key", map[string]string{"": "orders"}, "labels.", false},
+		{"key too long", map[string]string{strings.Repeat("k", maxLabelLength+1): ""}, "labels." + strings.Repeat("k", maxLabelLength+1), false},
+		{"value too long", map[string]string{"team": strings.Repeat("v", maxLabelLength+1)}, "labels.team", false},
+	}
+
+	for _, test := range tests {
+		info := m.validateLabels(test.labels)
+		if valid := info.Code == m.statusCodes.ok.Code; valid != test.valid {
+			t.Errorf("%s: got valid %v want %v, info: %s", test.name, valid, test.valid, info.Info)
+			continue
+		}
+
+		if info.Field != test.field {
+			t.Errorf("%s: got field %q want %q", test.name, info.Field, test.field)
+		}
+	}
+}
+
+func TestMatchesLabels(t *testing.T) {
+	labels := map[string]string{"team": "orders", "tier": "", "env": "prod:eu"}
+
+	tests := []struct {
+		name      string
+		selectors []string
+		want      bool
+	}{
+		{"no selectors", nil, true},
+		{"key only", []string{"team"}, true},
+		{"key and value", []string{"team:orders"}, true},
+		{"empty value", []string{"tier:"}, true},
+		{"value with colon", []string{"env:prod:eu"}, true},
+		{"all selectors must match", []string{"team:orders", "tier"}, true},
+		{"missing key", []string{"owner"}, false},
+		{"different value", []string{"team:billing"}, false},
+		{"one selector fails", []string{"team:orders", "owner"}, false},
+	}
+
+	for _, test := range tests {
+		if got := matchesLabels(labels, test.selectors); got != test.want {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
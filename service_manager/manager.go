@@ -34,9 +34,11 @@ func NewServiceMgr(config util.Config, rebalanceRunning bool, superSup common.Ev
 			rev:           0,
 			servers:       make([]service.NodeID, 0),
 		},
-		servers:      make([]service.NodeID, 0),
-		superSup:     superSup,
-		stopTracerCh: make(chan struct{}, 1),
+		servers:             make([]service.NodeID, 0),
+		statsHistory:        make(map[string]*statsRing),
+		statsHistoryRWMutex: &sync.RWMutex{},
+		superSup:            superSup,
+		stopTracerCh:        make(chan struct{}, 1),
 	}
 
 	mgr.config.Store(config)
@@ -48,6 +50,9 @@ func NewServiceMgr(config util.Config, rebalanceRunning bool, superSup common.Ev
 	mgr.servers = append(mgr.servers, mgr.nodeInfo.NodeID)
 	mgr.waiters = make(waiters)
 
+	go mgr.recordStatsHistory()
+	go mgr.watchConfig()
+
 	mgr.initService()
 	return mgr
 }
@@ -92,6 +97,7 @@ func (m *ServiceMgr) initService() {
 	http.HandleFunc("/getAggRebalanceProgress", m.getAggRebalanceProgress)
 	http.HandleFunc("/getAggRebalanceStatus", m.getAggRebalanceStatus)
 	http.HandleFunc("/getApplication/", m.getPrimaryStoreHandler)
+	http.HandleFunc("/getAppLog", m.getAppLog)
 	http.HandleFunc("/getAppTempStore/", m.getTempStoreHandler)
 	http.HandleFunc("/getBootstrappingApps", m.getBootstrappingApps)
 	http.HandleFunc("/getConsumerPids", m.getEventingConsumerPids)
@@ -109,6 +115,7 @@ func (m *ServiceMgr) initService() {
 	http.HandleFunc("/getRebalanceProgress", m.getRebalanceProgress)
 	http.HandleFunc("/getRebalanceStatus", m.getRebalanceStatus)
 	http.HandleFunc("/getSeqsProcessed", m.getSeqsProcessed)
+	http.HandleFunc("/getStatsHistory", m.getStatsHistoryHandler)
 	http.HandleFunc("/getLocalDebugUrl/", m.getLocalDebugURL)
 	http.HandleFunc("/parseQuery", m.parseQueryHandler)
 	http.HandleFunc("/saveAppTempStore/", m.saveTempStoreHandler)
@@ -122,10 +129,16 @@ func (m *ServiceMgr) initService() {
 
 	// Public REST APIs
 	http.HandleFunc("/api/v1/stats", m.statsHandler)
+	http.HandleFunc("/api/v1/stats/history", m.statsHistoryHandler)
+	http.HandleFunc("/api/v1/health", m.healthHandler)
+	http.HandleFunc("/metrics", m.metricsHandler)
 	http.HandleFunc("/api/v1/config", m.configHandler)
 	http.HandleFunc("/api/v1/config/", m.configHandler)
+	http.HandleFunc("/api/v1/settings/schema", m.settingsSchemaHandler)
 	http.HandleFunc("/api/v1/functions", m.functionsHandler)
 	http.HandleFunc("/api/v1/functions/", m.functionsHandler)
+	http.HandleFunc("/api/v1/export", m.exportHandler)
+	http.HandleFunc("/api/v1/import", m.importHandler)
 
 	go func() {
 		addr := net.JoinHostPort("", m.adminHTTPPort)
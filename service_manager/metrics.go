@@ -0,0 +1,257 @@
+package servicemanager
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/couchbase/eventing/util"
+)
+
+const (
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	metricCounter   = "counter"
+	metricGauge     = "gauge"
+	metricHistogram = "histogram"
+
+	// Mirror HIST_FROM, HIST_TILL and HIST_WIDTH of the cpp worker latency histogram,
+	// whose last bucket collects every sample at or beyond HIST_TILL
+	latencyHistFrom  = 100
+	latencyHistTill  = 1000 * 1000 * 10
+	latencyHistWidth = 1000
+
+	latencyOverflowBound = (latencyHistTill - latencyHistFrom) / latencyHistWidth * latencyHistWidth
+)
+
+type metricFamily struct {
+	name       string
+	metricType string
+	help       string
+	samples    bytes.Buffer
+}
+
+// Collects samples grouped by metric family, as the exposition format requires all
+// samples of a family to be contiguous
+type metricsRegistry struct {
+	families []*metricFamily
+	index    map[string]*metricFamily
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{index: make(map[string]*metricFamily)}
+}
+
+func (reg *metricsRegistry) family(name, metricType, help string) *metricFamily {
+	if f, ok := reg.index[name]; ok {
+		return f
+	}
+
+	f := &metricFamily{name: name, metricType: metricType, help: help}
+	reg.families = append(reg.families, f)
+	reg.index[name] = f
+	return f
+}
+
+// Labels are supplied as name, value pairs
+func (f *metricFamily) add(suffix string, value float64, labels ...string) {
+	f.samples.WriteString(f.name + suffix)
+
+	if len(labels) > 0 {
+		f.samples.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				f.samples.WriteByte(',')
+			}
+			fmt.Fprintf(&f.samples, "%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1]))
+		}
+		f.samples.WriteByte('}')
+	}
+
+	fmt.Fprintf(&f.samples, " %s\n", formatMetricValue(value))
+}
+
+func (reg *metricsRegistry) write(buf *bytes.Buffer) {
+	for _, f := range reg.families {
+		if f.samples.Len() == 0 {
+			continue
+		}
+
+		fmt.Fprintf(buf, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(buf, "# TYPE %s %s\n", f.name, f.metricType)
+		buf.Write(f.samples.Bytes())
+	}
+}
+
+func escapeLabelValue(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return strings.Replace(value, "\n", `\n`, -1)
+}
+
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Stats from cpp workers carry a timestamp along with counters, only numeric values are exported
+func addNumericStats(f *metricFamily, stats map[string]interface{}, labels ...string) {
+	for _, stat := range sortedKeys(stats) {
+		if value, ok := stats[stat].(float64); ok {
+			f.add("", value, append(labels, "stat", stat)...)
+		}
+	}
+}
+
+func addCounterStats(f *metricFamily, stats map[string]uint64, labelName string, labels ...string) {
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		f.add("", float64(stats[k]), append(labels, labelName, k)...)
+	}
+}
+
+// Latency stats from cpp workers map the bucket bound in microseconds to the number of
+// samples in that bucket. Buckets are made cumulative as the exposition format expects.
+// Samples in the overflow bucket have no upper bound and are only counted towards +Inf.
+// Sum is approximated using the bucket bounds.
+func addLatencyHistogram(f *metricFamily, latencyStats map[string]uint64, labels ...string) {
+	bounds := make([]uint64, 0, len(latencyStats))
+	counts := make(map[uint64]uint64)
+	var overflow uint64
+
+	for bucket, count := range latencyStats {
+		bound, err := strconv.ParseUint(bucket, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if bound >= latencyOverflowBound {
+			overflow += count
+			continue
+		}
+
+		if _, ok := counts[bound]; !ok {
+			bounds = append(bounds, bound)
+		}
+		counts[bound] += count
+	}
+	sort.Sort(util.Uint64Slice(bounds))
+
+	var cumulative uint64
+	var sum float64
+
+	for _, bound := range bounds {
+		cumulative += counts[bound]
+		sum += float64(bound) * float64(counts[bound]) / 1e6
+
+		f.add("_bucket", float64(cumulative), append(labels, "le", formatMetricValue(float64(bound)/1e6))...)
+	}
+
+	cumulative += overflow
+	sum += float64(latencyHistTill) * float64(overflow) / 1e6
+
+	f.add("_bucket", float64(cumulative), append(labels, "le", "+Inf")...)
+	f.add("_sum", sum, labels...)
+	f.add("_count", float64(cumulative), labels...)
+}
+
+// Flattens nested plasma stats, joining keys with '_'
+func addPlasmaStats(f *metricFamily, prefix string, stats map[string]interface{}, labels ...string) {
+	for _, stat := range sortedKeys(stats) {
+		name := stat
+		if prefix != "" {
+			name = prefix + "_" + stat
+		}
+
+		switch value := stats[stat].(type) {
+		case float64:
+			f.add("", value, append(labels, "stat", name)...)
+		case map[string]interface{}:
+			addPlasmaStats(f, name, value, labels...)
+		}
+	}
+}
+
+func (m *ServiceMgr) collectMetrics() *metricsRegistry {
+	reg := newMetricsRegistry()
+
+	eventsProcessed := reg.family("eventing_events_processed_total", metricCounter,
+		"DCP and timer events processed by the worker, by event type")
+	executions := reg.family("eventing_handler_executions_total", metricCounter,
+		"Handler executions by the worker, by outcome")
+	failures := reg.family("eventing_handler_failures_total", metricCounter,
+		"Handler failures by the worker, by reason")
+	lcbExceptions := reg.family("eventing_lcb_exceptions_total", metricCounter,
+		"Libcouchbase exceptions raised in the worker, by error code")
+	eventsRemaining := reg.family("eventing_dcp_events_remaining", metricGauge,
+		"DCP events yet to be processed by the worker")
+	latency := reg.family("eventing_handler_latency_seconds", metricHistogram,
+		"Handler execution latency, sum is approximated from bucket bounds")
+	plasma := reg.family("eventing_timer_store_stat", metricGauge,
+		"Stats of the plasma store housing timers of the function")
+
+	for _, app := range m.getTempStoreAll() {
+		if !m.checkIfDeployed(app.Name) {
+			continue
+		}
+
+		workerStats := m.superSup.GetWorkerStats(app.Name)
+
+		workers := make([]string, 0, len(workerStats))
+		for worker := range workerStats {
+			workers = append(workers, worker)
+		}
+		sort.Strings(workers)
+
+		for _, worker := range workers {
+			ws := workerStats[worker]
+			labels := []string{"function", app.Name, "worker", worker, "node", m.uuid}
+
+			addCounterStats(eventsProcessed, ws.EventProcessingStats, "event", labels...)
+			addNumericStats(executions, ws.ExecutionStats, labels...)
+			addNumericStats(failures, ws.FailureStats, labels...)
+			addCounterStats(lcbExceptions, ws.LcbExceptionStats, "code", labels...)
+			eventsRemaining.add("", float64(ws.DcpEventsRemaining), labels...)
+			addLatencyHistogram(latency, ws.LatencyStats, labels...)
+		}
+
+		if plasmaStats, err := m.superSup.GetPlasmaStats(app.Name); err == nil {
+			addPlasmaStats(plasma, "", plasmaStats, "function", app.Name, "node", m.uuid)
+		}
+	}
+
+	return reg
+}
+
+func (m *ServiceMgr) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metricsContentType)
+	if !m.validateAuth(w, r, EventingPermissionStats) {
+		return
+	}
+
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	var buf bytes.Buffer
+	m.collectMetrics().write(&buf)
+
+	w.Write(buf.Bytes())
+}
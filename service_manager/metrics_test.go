@@ -0,0 +1,56 @@
+package servicemanager
+
+import (
+	"testing"
+)
+
+func TestAddLatencyHistogram(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats map[string]uint64
+		want  string
+	}{
+		{
+			name:  "no samples",
+			stats: map[string]uint64{},
+			want: "latency_bucket{app=\"f\",le=\"+Inf\"} 0\n" +
+				"latency_sum{app=\"f\"} 0\n" +
+				"latency_count{app=\"f\"} 0\n",
+		},
+		{
+			name:  "buckets are cumulative",
+			stats: map[string]uint64{"100": 2, "2000": 1, "1000": 3},
+			want: "latency_bucket{app=\"f\",le=\"0.0001\"} 2\n" +
+				"latency_bucket{app=\"f\",le=\"0.001\"} 5\n" +
+				"latency_bucket{app=\"f\",le=\"0.002\"} 6\n" +
+				"latency_bucket{app=\"f\",le=\"+Inf\"} 6\n" +
+				"latency_sum{app=\"f\"} 0.0052\n" +
+				"latency_count{app=\"f\"} 6\n",
+		},
+		{
+			name:  "overflow only counted towards +Inf",
+			stats: map[string]uint64{"1000": 1, "9999000": 2},
+			want: "latency_bucket{app=\"f\",le=\"0.001\"} 1\n" +
+				"latency_bucket{app=\"f\",le=\"+Inf\"} 3\n" +
+				"latency_sum{app=\"f\"} 20.001\n" +
+				"latency_count{app=\"f\"} 3\n",
+		},
+		{
+			name:  "unparsable buckets skipped",
+			stats: map[string]uint64{"x": 4, "1000": 1},
+			want: "latency_bucket{app=\"f\",le=\"0.001\"} 1\n" +
+				"latency_bucket{app=\"f\",le=\"+Inf\"} 1\n" +
+				"latency_sum{app=\"f\"} 0.001\n" +
+				"latency_count{app=\"f\"} 1\n",
+		},
+	}
+
+	for _, test := range tests {
+		f := &metricFamily{name: "latency", metricType: metricHistogram}
+		addLatencyHistogram(f, test.stats, "app", "f")
+
+		if got := f.samples.String(); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/util"
+)
+
+type n1qlStatementReport struct {
+	util.N1QLStatement
+	util.N1QLUsage
+	MutatesSourceBucket bool `json:"mutates_source_bucket"`
+}
+
+type n1qlReport struct {
+	Function     string                `json:"function"`
+	SourceBucket string                `json:"source_bucket"`
+	Statements   []n1qlStatementReport `json:"statements"`
+}
+
+// DML on the source bucket is flagged, it's rejected on deploy as the transpiler rejects it
+func buildN1QLReport(app application) *n1qlReport {
+	report := &n1qlReport{
+		Function:     app.Name,
+		SourceBucket: app.DeploymentConfig.SourceBucket,
+		Statements:   make([]n1qlStatementReport, 0),
+	}
+
+	for _, stmt := range util.ExtractN1QLStatements(app.AppHandlers) {
+		usage := util.GetN1QLUsage(stmt.Query)
+
+		report.Statements = append(report.Statements, n1qlStatementReport{
+			N1QLStatement:       stmt,
+			N1QLUsage:           *usage,
+			MutatesSourceBucket: usage.IsDmlQuery && usage.Target == report.SourceBucket,
+		})
+	}
+
+	return report
+}
+
+func (m *ServiceMgr) n1qlReportHandler(w http.ResponseWriter, r *http.Request, appName string) {
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.FetchDrafts, r, appName)
+
+	app, info := m.getTempStore(appName)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	response, err := json.Marshal(buildN1QLReport(app))
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal N1QL report, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
@@ -0,0 +1,93 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/logging"
+)
+
+// Pause state is stored in settings, so that every eventing node picks it up through
+// the settings change notification. Paused function retains its checkpoints, timer store
+// and vbucket ownership.
+func (m *ServiceMgr) setProcessingPaused(appName string, paused bool) (info *runtimeInfo) {
+	logPrefix := "ServiceMgr::setProcessingPaused"
+
+	revs := m.getAppRevs(appName)
+	app, info := m.getTempStore(appName)
+	if info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
+	deploymentStatus, _ := app.Settings["deployment_status"].(bool)
+	processingStatus, _ := app.Settings["processing_status"].(bool)
+
+	deployedApps := m.superSup.GetDeployedApps()
+	if _, ok := deployedApps[appName]; !ok || !deploymentStatus || !processingStatus {
+		info.Code = m.statusCodes.errAppNotDeployed.Code
+		info.Info = fmt.Sprintf("Function: %s isn't deployed or processing mutations", appName)
+		return
+	}
+
+	isPaused, _ := app.Settings["processing_paused"].(bool)
+	if paused && isPaused {
+		info.Code = m.statusCodes.errAppPaused.Code
+		info.Info = fmt.Sprintf("Function: %s is already paused", appName)
+		return
+	}
+
+	if !paused && !isPaused {
+		info.Code = m.statusCodes.errAppNotPaused.Code
+		info.Info = fmt.Sprintf("Function: %s is not paused", appName)
+		return
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"processing_paused": paused})
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal settings as JSON, err : %v", err)
+		return
+	}
+
+	if info = m.setSettings(appName, data, revs); info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
+	logging.Infof("%s Function: %s processing_paused: %v", logPrefix, appName, paused)
+
+	if paused {
+		info.Info = fmt.Sprintf("Function: %s paused", appName)
+	} else {
+		info.Info = fmt.Sprintf("Function: %s resumed", appName)
+	}
+	return
+}
+
+// Disabling or undeploying a paused function lifts the pause
+func liftPauseIfStopped(settings map[string]interface{}) {
+	deploymentStatus, _ := settings["deployment_status"].(bool)
+	processingStatus, _ := settings["processing_status"].(bool)
+
+	if !deploymentStatus || !processingStatus {
+		settings["processing_paused"] = false
+	}
+}
+
+func (m *ServiceMgr) pauseResumeHandler(w http.ResponseWriter, r *http.Request, appName string, paused bool) {
+	if r.Method != "POST" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	event := auditevent.FunctionResumed
+	if paused {
+		event = auditevent.FunctionPaused
+	}
+	audit.Log(event, r, audit.Context{Function: appName})
+
+	info := m.setProcessingPaused(appName, paused)
+	m.sendErrorInfo(w, info)
+}
@@ -0,0 +1,34 @@
+package servicemanager
+
+import (
+	"testing"
+)
+
+func TestLiftPauseIfStopped(t *testing.T) {
+	tests := []struct {
+		name             string
+		deploymentStatus bool
+		processingStatus bool
+		processingPaused bool
+		wantPaused       bool
+	}{
+		{"undeployed", false, false, true, false},
+		{"disabled", true, false, true, false},
+		{"still deployed", true, true, true, true},
+		{"resumed", true, true, false, false},
+	}
+
+	for _, test := range tests {
+		settings := map[string]interface{}{
+			"deployment_status": test.deploymentStatus,
+			"processing_status": test.processingStatus,
+			"processing_paused": test.processingPaused,
+		}
+
+		liftPauseIfStopped(settings)
+
+		if got := settings["processing_paused"]; got != test.wantPaused {
+			t.Errorf("%s: got processing_paused %v want %v", test.name, got, test.wantPaused)
+		}
+	}
+}
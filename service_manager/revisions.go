@@ -0,0 +1,422 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/couchbase/cbauth"
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+type appRevision struct {
+	Revision       int          `json:"revision"`
+	Author         string       `json:"author"`
+	Timestamp      string       `json:"timestamp"`
+	Digest         string       `json:"digest"`
+	RolledBackFrom int          `json:"rolled_back_from,omitempty"`
+	App            *application `json:"app,omitempty"`
+}
+
+type revisionDiff struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	appDiff
+}
+
+// Returns the user on whose behalf the request is being made
+func getAuthor(r *http.Request) string {
+	creds, err := cbauth.AuthWebCreds(r)
+	if err != nil || creds == nil {
+		return ""
+	}
+	return creds.Name()
+}
+
+// Settings which reflect where the function is in its lifecycle rather than how it's defined,
+// hence neither restored on rollback nor considered when recording a revision
+var lifecycleSettings = []string{"deployment_status", "processing_status", "processing_paused"}
+
+func revisionsPath(appName string) string {
+	return metakvAppRevisionsPath + appName + "/"
+}
+
+func revisionsChecksumPath(appName string) string {
+	return metakvRevisionChecksumPath + appName + "/"
+}
+
+// Returns revision numbers stored for the app in ascending order
+func (m *ServiceMgr) listRevisionIDs(appName string) []int {
+	return parseRevisionIDs(appName, util.ListChildren(revisionsPath(appName)))
+}
+
+func parseRevisionIDs(appName string, children []string) []int {
+	logPrefix := "ServiceMgr::parseRevisionIDs"
+
+	var revisions []int
+	for _, child := range children {
+		revision, err := strconv.Atoi(child)
+		if err != nil || revision <= 0 {
+			logging.Warnf("%s Function: %s skipping unexpected revision entry: %s", logPrefix, appName, child)
+			continue
+		}
+		revisions = append(revisions, revision)
+	}
+
+	sort.Ints(revisions)
+	return revisions
+}
+
+// Revisions are numbered from 1 and never reused, even once older ones are pruned
+func nextRevisionID(revisions []int) int {
+	if len(revisions) == 0 {
+		return 1
+	}
+	return revisions[len(revisions)-1] + 1
+}
+
+// Returns the oldest revisions to prune so that at most max remain
+func revisionsToPrune(revisions []int, max int) []int {
+	if len(revisions) <= max {
+		return nil
+	}
+	return revisions[:len(revisions)-max]
+}
+
+func withoutLifecycleSettings(settings map[string]interface{}) map[string]interface{} {
+	definition := make(map[string]interface{}, len(settings))
+	for key, val := range settings {
+		definition[key] = val
+	}
+
+	for _, key := range lifecycleSettings {
+		delete(definition, key)
+	}
+	return definition
+}
+
+// Reports whether a and b define the same function, regardless of its lifecycle
+func sameDefinition(a, b application) bool {
+	a.Settings = withoutLifecycleSettings(a.Settings)
+	b.Settings = withoutLifecycleSettings(b.Settings)
+	return sameApplication(a, b)
+}
+
+func (m *ServiceMgr) getRevision(appName string, revision int) (rev appRevision, info *runtimeInfo) {
+	info = &runtimeInfo{}
+
+	data, err := util.ReadAppContent(revisionsPath(appName), revisionsChecksumPath(appName), strconv.Itoa(revision))
+	if err != nil || data == nil {
+		info.Code = m.statusCodes.errRevisionNotFound.Code
+		info.Info = fmt.Sprintf("Function: %s revision: %d not found", appName, revision)
+		return
+	}
+
+	err = json.Unmarshal(data, &rev)
+	if err != nil {
+		info.Code = m.statusCodes.errUnmarshalPld.Code
+		info.Info = fmt.Sprintf("Function: %s failed to unmarshal revision: %d, err: %v", appName, revision, err)
+		return
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+func (m *ServiceMgr) getRevisions(appName string) []appRevision {
+	logPrefix := "ServiceMgr::getRevisions"
+
+	revisions := make([]appRevision, 0)
+	for _, revision := range m.listRevisionIDs(appName) {
+		rev, info := m.getRevision(appName, revision)
+		if info.Code != m.statusCodes.ok.Code {
+			logging.Errorf("%s %s", logPrefix, info.Info)
+			continue
+		}
+
+		rev.App = nil
+		revisions = append(revisions, rev)
+	}
+
+	return revisions
+}
+
+// Records app as the latest revision in history, unless it only differs from the latest one in
+// lifecycle, as when it's deployed or paused. Oldest revisions beyond maxAppRevisions are pruned.
+func (m *ServiceMgr) saveRevision(app application, author string, rolledBackFrom int) (info *runtimeInfo) {
+	logPrefix := "ServiceMgr::saveRevision"
+	info = &runtimeInfo{}
+
+	revisions := m.listRevisionIDs(app.Name)
+
+	if len(revisions) > 0 && rolledBackFrom == 0 {
+		latest := revisions[len(revisions)-1]
+		if rev, revInfo := m.getRevision(app.Name, latest); revInfo.Code == m.statusCodes.ok.Code &&
+			rev.App != nil && sameDefinition(*rev.App, app) {
+			info.Code = m.statusCodes.ok.Code
+			info.Info = fmt.Sprintf("Function: %s unchanged since revision: %d", app.Name, latest)
+			return
+		}
+	}
+
+	next := nextRevisionID(revisions)
+
+	rev := appRevision{
+		Revision:       next,
+		Author:         author,
+		Timestamp:      time.Now().Format(time.RFC3339),
+		Digest:         util.GetHash(app.AppHandlers),
+		RolledBackFrom: rolledBackFrom,
+		App:            &app,
+	}
+
+	data, err := json.Marshal(&rev)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Function: %s failed to marshal revision: %d, err: %v", app.Name, next, err)
+		return
+	}
+
+	err = util.WriteAppContent(revisionsPath(app.Name), revisionsChecksumPath(app.Name), strconv.Itoa(next), data)
+	if err != nil {
+		info.Code = m.statusCodes.errSaveRevision.Code
+		info.Info = fmt.Sprintf("Function: %s failed to store revision: %d, err: %v", app.Name, next, err)
+		return
+	}
+
+	for _, revision := range revisionsToPrune(append(revisions, next), maxAppRevisions) {
+		err = util.DeleteAppContent(revisionsPath(app.Name), revisionsChecksumPath(app.Name), strconv.Itoa(revision))
+		if err != nil {
+			logging.Errorf("%s Function: %s failed to prune revision: %d, err: %v",
+				logPrefix, app.Name, revision, err)
+			break
+		}
+	}
+
+	logging.Infof("%s Function: %s stored revision: %d digest: %s", logPrefix, app.Name, next, rev.Digest)
+
+	info.Code = m.statusCodes.ok.Code
+	info.Info = fmt.Sprintf("Stored revision: %d for function: %s", next, app.Name)
+	return
+}
+
+// Records app as a revision once it has been saved. Failing to do so leaves the save in place,
+// so it's only logged rather than failing the request that saved it.
+func (m *ServiceMgr) recordRevision(app application, author string, rolledBackFrom int) {
+	logPrefix := "ServiceMgr::recordRevision"
+
+	if info := m.saveRevision(app, author, rolledBackFrom); info.Code != m.statusCodes.ok.Code {
+		logging.Errorf("%s %s", logPrefix, info.Info)
+	}
+}
+
+func (m *ServiceMgr) deleteRevisions(appName string) {
+	logPrefix := "ServiceMgr::deleteRevisions"
+
+	if err := util.RecursiveDelete(revisionsPath(appName)); err != nil {
+		logging.Errorf("%s Function: %s failed to delete revisions, err: %v", logPrefix, appName, err)
+	}
+
+	if err := util.RecursiveDelete(revisionsChecksumPath(appName)); err != nil {
+		logging.Errorf("%s Function: %s failed to delete revision checksums, err: %v", logPrefix, appName, err)
+	}
+}
+
+// Stores an earlier revision of the app in primary and temp store and records it as the latest revision.
+// Function must not be deployed. Revision may have been recorded while it was, so lifecycle settings are
+// taken from the current function instead.
+func (m *ServiceMgr) rollbackToRevision(appName string, revision int, author string) (info *runtimeInfo) {
+	logPrefix := "ServiceMgr::rollbackToRevision"
+	revs := m.getAppRevs(appName)
+
+	if m.checkIfDeployed(appName) {
+		info = &runtimeInfo{}
+		info.Code = m.statusCodes.errAppDeployed.Code
+		info.Info = fmt.Sprintf("Function: %s is deployed, undeploy it before rolling back", appName)
+		return
+	}
+
+	rev, info := m.getRevision(appName, revision)
+	if info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
+	app := *rev.App
+	if app.Name != appName {
+		info.Code = m.statusCodes.errAppNameMismatch.Code
+		info.Info = fmt.Sprintf("Function name in the URL (%s) and revision (%s) must be same", appName, app.Name)
+		return
+	}
+
+	current, info := m.getTempStore(appName)
+	if info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
+	app.Settings = withoutLifecycleSettings(app.Settings)
+	for _, key := range lifecycleSettings {
+		if val, ok := current.Settings[key]; ok {
+			app.Settings[key] = val
+		}
+	}
+
+	if info = m.savePrimaryStore(app, revs); info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
+	if info = m.saveTempStore(app); info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
+	m.recordRevision(app, author, revision)
+
+	logging.Infof("%s Function: %s rolled back to revision: %d", logPrefix, appName, revision)
+
+	info.Code = m.statusCodes.ok.Code
+	info.Info = fmt.Sprintf("Function: %s rolled back to revision: %d", appName, revision)
+	return
+}
+
+func (m *ServiceMgr) parseRevision(appName, value string) (revision int, info *runtimeInfo) {
+	info = &runtimeInfo{}
+
+	revision, err := strconv.Atoi(value)
+	if err != nil || revision <= 0 {
+		info.Code = m.statusCodes.errRevisionNotFound.Code
+		info.Info = fmt.Sprintf("Function: %s invalid revision: %s", appName, value)
+		return
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+func (m *ServiceMgr) revisionsHandler(w http.ResponseWriter, r *http.Request, appName string) {
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.RevisionsFetched, r, audit.Context{Function: appName})
+
+	response, err := json.Marshal(m.getRevisions(appName))
+	if err != nil {
+		info := &runtimeInfo{}
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal revisions, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
+
+func (m *ServiceMgr) revisionHandler(w http.ResponseWriter, r *http.Request, appName, revisionParam string) {
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.RevisionsFetched, r, audit.Context{Function: appName})
+
+	revision, info := m.parseRevision(appName, revisionParam)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	rev, info := m.getRevision(appName, revision)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	response, err := json.Marshal(rev)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal revision, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
+
+func (m *ServiceMgr) revisionsDiffHandler(w http.ResponseWriter, r *http.Request, appName string) {
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.RevisionsFetched, r, audit.Context{Function: appName})
+
+	params := r.URL.Query()
+
+	from, info := m.parseRevision(appName, params.Get("from"))
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	to, info := m.parseRevision(appName, params.Get("to"))
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	fromRev, info := m.getRevision(appName, from)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	toRev, info := m.getRevision(appName, to)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	diff := revisionDiff{
+		From: from,
+		To:   to,
+		appDiff: diffApplications(*fromRev.App, *toRev.App,
+			fmt.Sprintf("%s (revision %d)", appName, from), fmt.Sprintf("%s (revision %d)", appName, to)),
+	}
+
+	response, err := json.Marshal(diff)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal revision diff, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
+
+func (m *ServiceMgr) rollbackHandler(w http.ResponseWriter, r *http.Request, appName, revisionParam string) {
+	if r.Method != "POST" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	revision, info := m.parseRevision(appName, revisionParam)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	audit.Log(auditevent.FunctionRolledBack, r, audit.Context{Function: appName, NewValue: revision})
+
+	info = m.rollbackToRevision(appName, revision, getAuthor(r))
+	m.sendErrorInfo(w, info)
+}
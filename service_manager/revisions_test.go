@@ -0,0 +1,121 @@
+package servicemanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRevisionIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		children []string
+		want     []int
+	}{
+		{"none", nil, nil},
+		{"sorted numerically", []string{"10", "2", "1"}, []int{1, 2, 10}},
+		{"unexpected entries skipped", []string{"3", "tmp", "-1", "0", "1"}, []int{1, 3}},
+	}
+
+	for _, test := range tests {
+		if got := parseRevisionIDs("app", test.children); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNextRevisionID(t *testing.T) {
+	tests := []struct {
+		name      string
+		revisions []int
+		want      int
+	}{
+		{"first revision", nil, 1},
+		{"follows latest", []int{1, 2, 3}, 4},
+		{"not reused once older ones are pruned", []int{48, 49, 50}, 51},
+	}
+
+	for _, test := range tests {
+		if got := nextRevisionID(test.revisions); got != test.want {
+			t.Errorf("%s: got %d want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRevisionsToPrune(t *testing.T) {
+	tests := []struct {
+		name      string
+		revisions []int
+		max       int
+		want      []int
+	}{
+		{"under limit", []int{1, 2}, 3, nil},
+		{"at limit", []int{1, 2, 3}, 3, nil},
+		{"oldest pruned", []int{4, 5, 6, 7, 8}, 3, []int{4, 5}},
+	}
+
+	for _, test := range tests {
+		if got := revisionsToPrune(test.revisions, test.max); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSameDefinition(t *testing.T) {
+	base := func() application {
+		return application{
+			Name:             "app",
+			AppHandlers:      "function OnUpdate(doc, meta) {}",
+			DeploymentConfig: depCfg{SourceBucket: "src", MetadataBucket: "meta"},
+			Settings: map[string]interface{}{
+				"deployment_status": false,
+				"processing_status": false,
+				"worker_count":      float64(3),
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(app *application)
+		want   bool
+	}{
+		{"unchanged", func(app *application) {}, true},
+		{"deployed", func(app *application) {
+			app.Settings["deployment_status"] = true
+			app.Settings["processing_status"] = true
+		}, true},
+		{"paused", func(app *application) { app.Settings["processing_paused"] = true }, true},
+		{"handler changed", func(app *application) { app.AppHandlers = "function OnDelete(meta) {}" }, false},
+		{"depcfg changed", func(app *application) { app.DeploymentConfig.SourceBucket = "other" }, false},
+		{"setting changed", func(app *application) { app.Settings["worker_count"] = float64(4) }, false},
+		{"setting added", func(app *application) { app.Settings["log_level"] = "TRACE" }, false},
+		{"label added", func(app *application) { app.Labels = map[string]string{"team": "orders"} }, false},
+	}
+
+	for _, test := range tests {
+		a, b := base(), base()
+		test.change(&b)
+
+		if got := sameDefinition(a, b); got != test.want {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestWithoutLifecycleSettings(t *testing.T) {
+	settings := map[string]interface{}{
+		"deployment_status": true,
+		"processing_status": true,
+		"processing_paused": false,
+		"worker_count":      float64(3),
+	}
+
+	got := withoutLifecycleSettings(settings)
+	if want := map[string]interface{}{"worker_count": float64(3)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if len(settings) != 4 {
+		t.Errorf("settings passed in were modified: %v", settings)
+	}
+}
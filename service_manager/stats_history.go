@@ -0,0 +1,397 @@
+package servicemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/couchbase/eventing/audit"
+	"github.com/couchbase/eventing/gen/auditevent"
+	"github.com/couchbase/eventing/logging"
+	"github.com/couchbase/eventing/util"
+)
+
+var latencyPercentiles = []struct {
+	name     string
+	quantile float64
+}{
+	{"p50", 0.5},
+	{"p90", 0.9},
+	{"p99", 0.99},
+}
+
+type statsSample struct {
+	Timestamp    string `json:"timestamp"`
+	Nodes        int    `json:"nodes,omitempty"`
+	DcpOpsPSec   int    `json:"dcp_ops_psec"`
+	TimerOpsPSec int    `json:"timer_ops_psec"`
+	DcpBacklog   uint64 `json:"dcp_backlog"`
+
+	// Failure counters at the time of sampling
+	Failures map[string]float64 `json:"failures"`
+
+	// Handler latency in microseconds, over executions since the previous sample
+	Latency map[string]uint64 `json:"latency_usec"`
+}
+
+type statsHistory struct {
+	FunctionName string        `json:"function_name"`
+	Samples      []statsSample `json:"samples"`
+}
+
+// Bounded history of samples of a function, oldest samples are overwritten once full
+type statsRing struct {
+	samples []statsSample
+	next    int
+	full    bool
+
+	// Latency histogram as of previous sample
+	lastLatency map[string]uint64
+}
+
+func newStatsRing(size int) *statsRing {
+	return &statsRing{
+		samples:     make([]statsSample, size),
+		lastLatency: make(map[string]uint64),
+	}
+}
+
+func (ring *statsRing) add(sample statsSample) {
+	ring.samples[ring.next] = sample
+	ring.next = (ring.next + 1) % len(ring.samples)
+	if ring.next == 0 {
+		ring.full = true
+	}
+}
+
+// Returns samples taken after since, oldest first
+func (ring *statsRing) since(since time.Time) []statsSample {
+	ordered := ring.samples[:ring.next]
+	if ring.full {
+		ordered = append(append([]statsSample{}, ring.samples[ring.next:]...), ring.samples[:ring.next]...)
+	}
+
+	samples := make([]statsSample, 0)
+	for _, sample := range ordered {
+		ts, err := time.Parse(time.RFC3339, sample.Timestamp)
+		if err == nil && ts.After(since) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+// Percentiles of latency samples recorded since the previous histogram. Counters going
+// down means stats were reset, in which case the current histogram is used as is.
+func latencyPercentilesSince(current, previous map[string]uint64) map[string]uint64 {
+	bounds := make([]uint64, 0)
+	counts := make(map[uint64]uint64)
+	var total uint64
+
+	reset := false
+	for bucket, count := range current {
+		if count < previous[bucket] {
+			reset = true
+		}
+	}
+
+	for bucket, count := range current {
+		bound, err := strconv.ParseUint(bucket, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if !reset {
+			count -= previous[bucket]
+		}
+		if count == 0 {
+			continue
+		}
+
+		bounds = append(bounds, bound)
+		counts[bound] += count
+		total += count
+	}
+	sort.Sort(util.Uint64Slice(bounds))
+
+	percentiles := make(map[string]uint64)
+	if total == 0 {
+		return percentiles
+	}
+
+	for _, p := range latencyPercentiles {
+		var cumulative uint64
+		for _, bound := range bounds {
+			cumulative += counts[bound]
+			if float64(cumulative) >= p.quantile*float64(total) {
+				percentiles[p.name] = bound
+				break
+			}
+		}
+	}
+	return percentiles
+}
+
+func (m *ServiceMgr) sampleStats(ts time.Time) {
+	deployed := make(map[string]struct{})
+
+	for _, appName := range m.superSup.DeployedAppList() {
+		deployed[appName] = struct{}{}
+
+		sample := statsSample{
+			Timestamp:  ts.Format(time.RFC3339),
+			DcpBacklog: m.superSup.GetDcpEventsRemainingToProcess(appName),
+			Failures:   make(map[string]float64),
+		}
+
+		if rates := m.superSup.GetEventsProcessedPSec(appName); rates != nil {
+			sample.DcpOpsPSec = rates.DcpEventsProcessedPSec
+			sample.TimerOpsPSec = rates.TimerEventsProcessedPSec
+		}
+
+		for stat, value := range m.superSup.GetFailureStats(appName) {
+			if v, ok := value.(float64); ok {
+				sample.Failures[stat] = v
+			}
+		}
+
+		latency := m.superSup.GetLatencyStats(appName)
+
+		m.statsHistoryRWMutex.Lock()
+		ring, ok := m.statsHistory[appName]
+		if !ok {
+			ring = newStatsRing(statsHistorySize)
+			m.statsHistory[appName] = ring
+		}
+		sample.Latency = latencyPercentilesSince(latency, ring.lastLatency)
+		if latency != nil {
+			ring.lastLatency = latency
+		}
+		ring.add(sample)
+		m.statsHistoryRWMutex.Unlock()
+	}
+
+	// History outlives undeployment, but not deletion of the function
+	functions := make(map[string]struct{})
+	for _, app := range m.getTempStoreAll() {
+		functions[app.Name] = struct{}{}
+	}
+
+	m.statsHistoryRWMutex.Lock()
+	defer m.statsHistoryRWMutex.Unlock()
+
+	for appName := range m.statsHistory {
+		_, isFunction := functions[appName]
+		_, isDeployed := deployed[appName]
+		if !isFunction && !isDeployed {
+			delete(m.statsHistory, appName)
+		}
+	}
+}
+
+// Samples are aligned to the sampling interval, so that samples from several nodes could be merged
+func (m *ServiceMgr) recordStatsHistory() {
+	ticker := time.NewTicker(statsHistoryInterval)
+	defer ticker.Stop()
+
+	for ts := range ticker.C {
+		m.sampleStats(ts.UTC().Truncate(statsHistoryInterval))
+	}
+}
+
+func (m *ServiceMgr) getStatsHistory(appName string, since time.Time) []statsHistory {
+	m.statsHistoryRWMutex.RLock()
+	defer m.statsHistoryRWMutex.RUnlock()
+
+	history := make([]statsHistory, 0)
+	for name, ring := range m.statsHistory {
+		if appName == "" || appName == name {
+			history = append(history, statsHistory{FunctionName: name, Samples: ring.since(since)})
+		}
+	}
+	return history
+}
+
+// Rates, backlog and failures are summed up across nodes, while latency is the worst among nodes
+func mergeStatsHistory(nodeHistories [][]statsHistory) []statsHistory {
+	merged := make(map[string]map[string]*statsSample)
+
+	for _, nodeHistory := range nodeHistories {
+		for _, history := range nodeHistory {
+			if _, ok := merged[history.FunctionName]; !ok {
+				merged[history.FunctionName] = make(map[string]*statsSample)
+			}
+
+			for _, sample := range history.Samples {
+				m, ok := merged[history.FunctionName][sample.Timestamp]
+				if !ok {
+					m = &statsSample{
+						Timestamp: sample.Timestamp,
+						Failures:  make(map[string]float64),
+						Latency:   make(map[string]uint64),
+					}
+					merged[history.FunctionName][sample.Timestamp] = m
+				}
+
+				m.Nodes++
+				m.DcpOpsPSec += sample.DcpOpsPSec
+				m.TimerOpsPSec += sample.TimerOpsPSec
+				m.DcpBacklog += sample.DcpBacklog
+
+				for stat, value := range sample.Failures {
+					m.Failures[stat] += value
+				}
+
+				for p, value := range sample.Latency {
+					if value > m.Latency[p] {
+						m.Latency[p] = value
+					}
+				}
+			}
+		}
+	}
+
+	history := make([]statsHistory, 0)
+	for appName, samples := range merged {
+		timestamps := make([]string, 0, len(samples))
+		for ts := range samples {
+			timestamps = append(timestamps, ts)
+		}
+		sort.Strings(timestamps)
+
+		h := statsHistory{FunctionName: appName, Samples: make([]statsSample, 0, len(samples))}
+		for _, ts := range timestamps {
+			h.Samples = append(h.Samples, *samples[ts])
+		}
+		history = append(history, h)
+	}
+
+	return history
+}
+
+func (m *ServiceMgr) getClusterStatsHistory(urlSuffix string) ([]statsHistory, error) {
+	logPrefix := "ServiceMgr::getClusterStatsHistory"
+
+	util.Retry(util.NewFixedBackoff(time.Second), getEventingNodesAddressesOpCallback, m)
+
+	netClient := util.NewClient(util.HTTPRequestTimeout)
+	nodeHistories := make([][]statsHistory, 0)
+
+	for _, nodeAddr := range m.eventingNodeAddrs {
+		endpointURL := fmt.Sprintf("http://%s%s", nodeAddr, urlSuffix)
+
+		res, err := netClient.Get(endpointURL)
+		if err != nil {
+			logging.Errorf("%s Failed to gather stats history from url: %rs, err: %v", logPrefix, endpointURL, err)
+			return nil, err
+		}
+
+		buf, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			logging.Errorf("%s Failed to read response body for stats history from url: %rs, err: %v", logPrefix, endpointURL, err)
+			return nil, err
+		}
+
+		var history []statsHistory
+		err = json.Unmarshal(buf, &history)
+		if err != nil {
+			logging.Errorf("%s Failed to unmarshal stats history from url: %rs, err: %v", logPrefix, endpointURL, err)
+			return nil, err
+		}
+
+		nodeHistories = append(nodeHistories, history)
+	}
+
+	return mergeStatsHistory(nodeHistories), nil
+}
+
+func (m *ServiceMgr) parseStatsHistoryParams(params url.Values) (appName string, since time.Time, info *runtimeInfo) {
+	info = &runtimeInfo{}
+	appName = params.Get("function")
+
+	if value := params.Get("since"); value != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			info.Code = m.statusCodes.errInvalidConfig.Code
+			info.Info = fmt.Sprintf("since must be a RFC3339 timestamp, got: %s", value)
+			return
+		}
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
+func (m *ServiceMgr) statsHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !m.validateAuth(w, r, EventingPermissionStats) {
+		return
+	}
+
+	if r.Method != "GET" {
+		m.sendMethodNotAllowed(w, r)
+		return
+	}
+
+	audit.Log(auditevent.FetchFunctions, r, nil)
+
+	params := r.URL.Query()
+	appName, since, info := m.parseStatsHistoryParams(params)
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	var history []statsHistory
+	if params.Get("aggregate") == "true" {
+		var err error
+		history, err = m.getClusterStatsHistory("/getStatsHistory?" + r.URL.RawQuery)
+		if err != nil {
+			info.Code = m.statusCodes.errActiveEventingNodes.Code
+			info.Info = fmt.Sprintf("Failed to get stats history from all eventing nodes, err: %v", err)
+			m.sendErrorInfo(w, info)
+			return
+		}
+	} else {
+		history = m.getStatsHistory(appName, since)
+	}
+
+	response, err := json.Marshal(history)
+	if err != nil {
+		info.Code = m.statusCodes.errMarshalResp.Code
+		info.Info = fmt.Sprintf("Failed to marshal stats history, err : %v", err)
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.ok.Code))
+	fmt.Fprintf(w, "%s", string(response))
+}
+
+// Serves stats history of this node, to be merged by the node handling the REST call
+func (m *ServiceMgr) getStatsHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if !m.validateAuth(w, r, EventingPermissionStats) {
+		return
+	}
+
+	appName, since, info := m.parseStatsHistoryParams(r.URL.Query())
+	if info.Code != m.statusCodes.ok.Code {
+		m.sendErrorInfo(w, info)
+		return
+	}
+
+	buf, err := json.Marshal(m.getStatsHistory(appName, since))
+	if err != nil {
+		logging.Errorf("Failed to marshal stats history, err: %v", err)
+		return
+	}
+
+	fmt.Fprintf(w, "%s", string(buf))
+}
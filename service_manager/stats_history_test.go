@@ -0,0 +1,149 @@
+package servicemanager
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestStatsRing(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(i int) statsSample {
+		return statsSample{Timestamp: start.Add(time.Duration(i) * time.Second).Format(time.RFC3339), DcpOpsPSec: i}
+	}
+
+	tests := []struct {
+		name  string
+		size  int
+		added int
+		since time.Time
+		want  []int
+	}{
+		{"empty", 3, 0, time.Time{}, []int{}},
+		{"not full", 3, 2, time.Time{}, []int{1, 2}},
+		{"full", 3, 3, time.Time{}, []int{1, 2, 3}},
+		{"oldest overwritten", 3, 5, time.Time{}, []int{3, 4, 5}},
+		{"since excludes older samples", 3, 5, start.Add(4 * time.Second), []int{5}},
+		{"since beyond latest sample", 3, 5, start.Add(5 * time.Second), []int{}},
+	}
+
+	for _, test := range tests {
+		ring := newStatsRing(test.size)
+		for i := 1; i <= test.added; i++ {
+			ring.add(sample(i))
+		}
+
+		got := make([]int, 0)
+		for _, s := range ring.since(test.since) {
+			got = append(got, s.DcpOpsPSec)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLatencyPercentilesSince(t *testing.T) {
+	tests := []struct {
+		name              string
+		current, previous map[string]uint64
+		want              map[string]uint64
+	}{
+		{
+			name:    "no samples",
+			current: map[string]uint64{},
+			want:    map[string]uint64{},
+		},
+		{
+			name:    "first sample",
+			current: map[string]uint64{"100": 50, "1000": 40, "2000": 9, "5000": 1},
+			want:    map[string]uint64{"p50": 100, "p90": 1000, "p99": 2000},
+		},
+		{
+			name:     "only samples since previous histogram",
+			current:  map[string]uint64{"100": 50, "1000": 50},
+			previous: map[string]uint64{"100": 50},
+			want:     map[string]uint64{"p50": 1000, "p90": 1000, "p99": 1000},
+		},
+		{
+			name:     "nothing since previous histogram",
+			current:  map[string]uint64{"100": 50},
+			previous: map[string]uint64{"100": 50},
+			want:     map[string]uint64{},
+		},
+		{
+			name:     "counters reset",
+			current:  map[string]uint64{"100": 1, "1000": 1},
+			previous: map[string]uint64{"100": 50},
+			want:     map[string]uint64{"p50": 100, "p90": 1000, "p99": 1000},
+		},
+	}
+
+	for _, test := range tests {
+		if got := latencyPercentilesSince(test.current, test.previous); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMergeStatsHistory(t *testing.T) {
+	ts1, ts2 := "2018-01-01T00:00:00Z", "2018-01-01T00:00:10Z"
+
+	tests := []struct {
+		name          string
+		nodeHistories [][]statsHistory
+		want          []statsHistory
+	}{
+		{
+			name:          "no nodes",
+			nodeHistories: nil,
+			want:          []statsHistory{},
+		},
+		{
+			name: "summed and worst latency across nodes",
+			nodeHistories: [][]statsHistory{
+				{{FunctionName: "f", Samples: []statsSample{
+					{Timestamp: ts1, DcpOpsPSec: 10, DcpBacklog: 5, Failures: map[string]float64{"timeout_count": 1}, Latency: map[string]uint64{"p50": 100}},
+				}}},
+				{{FunctionName: "f", Samples: []statsSample{
+					{Timestamp: ts1, DcpOpsPSec: 20, DcpBacklog: 1, Failures: map[string]float64{"timeout_count": 2}, Latency: map[string]uint64{"p50": 300}},
+				}}},
+			},
+			want: []statsHistory{{FunctionName: "f", Samples: []statsSample{
+				{Timestamp: ts1, Nodes: 2, DcpOpsPSec: 30, DcpBacklog: 6, Failures: map[string]float64{"timeout_count": 3}, Latency: map[string]uint64{"p50": 300}},
+			}}},
+		},
+		{
+			name: "samples ordered by timestamp, per function",
+			nodeHistories: [][]statsHistory{
+				{
+					{FunctionName: "f", Samples: []statsSample{{Timestamp: ts2, TimerOpsPSec: 2}}},
+					{FunctionName: "g", Samples: []statsSample{{Timestamp: ts1, TimerOpsPSec: 3}}},
+				},
+				{
+					{FunctionName: "f", Samples: []statsSample{{Timestamp: ts1, TimerOpsPSec: 1}}},
+				},
+			},
+			want: []statsHistory{
+				{FunctionName: "f", Samples: []statsSample{
+					{Timestamp: ts1, Nodes: 1, TimerOpsPSec: 1, Failures: map[string]float64{}, Latency: map[string]uint64{}},
+					{Timestamp: ts2, Nodes: 1, TimerOpsPSec: 2, Failures: map[string]float64{}, Latency: map[string]uint64{}},
+				}},
+				{FunctionName: "g", Samples: []statsSample{
+					{Timestamp: ts1, Nodes: 1, TimerOpsPSec: 3, Failures: map[string]float64{}, Latency: map[string]uint64{}},
+				}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := mergeStatsHistory(test.nodeHistories)
+		sort.Slice(got, func(i, j int) bool { return got[i].FunctionName < got[j].FunctionName })
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v want %+v", test.name, got, test.want)
+		}
+	}
+}
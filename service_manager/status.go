@@ -25,11 +25,14 @@ type errorPayload struct {
 	Description string   `json:"description"`
 	Attributes  []string `json:"attributes"`
 	RuntimeInfo string   `json:"runtime_info"`
+	HTTPStatus  int      `json:"http_status,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
 }
 
 type runtimeInfo struct {
-	Code int    `json:"code"`
-	Info string `json:"info"`
+	Code  int    `json:"code"`
+	Info  string `json:"info"`
+	Field string `json:"field,omitempty"` // path of the offending field in request, e.g. settings.worker_count
 }
 
 type statusCodes struct {
@@ -71,6 +74,20 @@ type statusCodes struct {
 	errActiveEventingNodes statusBase
 	errInvalidConfig       statusBase
 	errAppCodeSize         statusBase
+	errRevisionNotFound    statusBase
+	errSaveRevision        statusBase
+	errFunctionExists      statusBase
+	errImportAborted       statusBase
+	errAppPaused           statusBase
+	errAppNotPaused        statusBase
+	errInvalidN1QL         statusBase
+	errAppValidation       statusBase
+	errGetAppLog           statusBase
+	errPreconditionFailed  statusBase
+	errMutationCycle       statusBase
+	errUnauthenticated     statusBase
+	errForbidden           statusBase
+	errMethodNotAllowed    statusBase
 }
 
 func (m *ServiceMgr) getDisposition(code int) int {
@@ -81,6 +98,8 @@ func (m *ServiceMgr) getDisposition(code int) int {
 		return http.StatusInternalServerError
 	case m.statusCodes.errDelAppTs.Code:
 		return http.StatusInternalServerError
+	case m.statusCodes.errGetAppPs.Code:
+		return http.StatusInternalServerError
 	case m.statusCodes.errSaveAppPs.Code:
 		return http.StatusInternalServerError
 	case m.statusCodes.errSaveAppTs.Code:
@@ -141,6 +160,34 @@ func (m *ServiceMgr) getDisposition(code int) int {
 		return http.StatusBadRequest
 	case m.statusCodes.errAppCodeSize.Code:
 		return http.StatusBadRequest
+	case m.statusCodes.errRevisionNotFound.Code:
+		return http.StatusNotFound
+	case m.statusCodes.errSaveRevision.Code:
+		return http.StatusInternalServerError
+	case m.statusCodes.errFunctionExists.Code:
+		return http.StatusConflict
+	case m.statusCodes.errImportAborted.Code:
+		return http.StatusUnprocessableEntity
+	case m.statusCodes.errAppPaused.Code:
+		return http.StatusNotAcceptable
+	case m.statusCodes.errAppNotPaused.Code:
+		return http.StatusNotAcceptable
+	case m.statusCodes.errInvalidN1QL.Code:
+		return http.StatusBadRequest
+	case m.statusCodes.errAppValidation.Code:
+		return http.StatusUnprocessableEntity
+	case m.statusCodes.errGetAppLog.Code:
+		return http.StatusInternalServerError
+	case m.statusCodes.errPreconditionFailed.Code:
+		return http.StatusPreconditionFailed
+	case m.statusCodes.errMutationCycle.Code:
+		return http.StatusUnprocessableEntity
+	case m.statusCodes.errUnauthenticated.Code:
+		return http.StatusUnauthorized
+	case m.statusCodes.errForbidden.Code:
+		return http.StatusForbidden
+	case m.statusCodes.errMethodNotAllowed.Code:
+		return http.StatusMethodNotAllowed
 	default:
 		logging.Warnf("Unknown status code: %v", code)
 		return http.StatusInternalServerError
@@ -152,6 +199,7 @@ func (m *ServiceMgr) initErrCodes() {
 		ok:                     statusBase{"OK", 0},
 		errDelAppPs:            statusBase{"ERR_DEL_APP_PS", 1},
 		errDelAppTs:            statusBase{"ERR_DEL_APP_TS", 2},
+		errGetAppPs:            statusBase{"ERR_GET_APP_PS", 3},
 		errSaveAppPs:           statusBase{"ERR_SAVE_APP_PS", 5},
 		errSaveAppTs:           statusBase{"ERR_SAVE_APP_TS", 6},
 		errSetSettingsPs:       statusBase{"ERR_SET_SETTINGS_PS", 7},
@@ -183,6 +231,20 @@ func (m *ServiceMgr) initErrCodes() {
 		errActiveEventingNodes: statusBase{"ERR_FETCHING_ACTIVE_EVENTING_NODES", 37},
 		errInvalidConfig:       statusBase{"ERR_INVALID_CONFIG", 38},
 		errAppCodeSize:         statusBase{"ERR_APPCODE_SIZE", 39},
+		errRevisionNotFound:    statusBase{"ERR_REVISION_NOT_FOUND", 40},
+		errSaveRevision:        statusBase{"ERR_SAVE_REVISION", 41},
+		errFunctionExists:      statusBase{"ERR_FUNCTION_EXISTS", 42},
+		errImportAborted:       statusBase{"ERR_IMPORT_ABORTED", 43},
+		errAppPaused:           statusBase{"ERR_APP_PAUSED", 44},
+		errAppNotPaused:        statusBase{"ERR_APP_NOT_PAUSED", 45},
+		errInvalidN1QL:         statusBase{"ERR_INVALID_N1QL", 46},
+		errAppValidation:       statusBase{"ERR_APP_VALIDATION", 47},
+		errGetAppLog:           statusBase{"ERR_GET_APP_LOG", 48},
+		errPreconditionFailed:  statusBase{"ERR_PRECONDITION_FAILED", 49},
+		errMutationCycle:       statusBase{"ERR_MUTATION_CYCLE", 50},
+		errUnauthenticated:     statusBase{"ERR_UNAUTHENTICATED", 51},
+		errForbidden:           statusBase{"ERR_FORBIDDEN", 52},
+		errMethodNotAllowed:    statusBase{"ERR_METHOD_NOT_ALLOWED", 53},
 	}
 
 	errors := []errorPayload{
@@ -190,201 +252,324 @@ func (m *ServiceMgr) initErrCodes() {
 			Name:        m.statusCodes.errDelAppPs.Name,
 			Code:        m.statusCodes.errDelAppPs.Code,
 			Description: "Unable to delete application from primary store",
+			Remediation: "Retry the delete, check that metakv is reachable from the eventing node",
 		},
 		{
 			Name:        m.statusCodes.errDelAppTs.Name,
 			Code:        m.statusCodes.errDelAppTs.Code,
 			Description: "Unable to delete application from temporary store",
+			Remediation: "Retry the delete, check that metakv is reachable from the eventing node",
 		},
 		{
 			Name:        m.statusCodes.errGetAppPs.Name,
 			Code:        m.statusCodes.errGetAppPs.Code,
 			Description: "Unable to get application from primary store",
+			Remediation: "Retry the request, check that the function is deployed and metakv is reachable",
 			Attributes:  []string{"retry"},
 		},
 		{
 			Name:        m.statusCodes.getAppTs.Name,
 			Code:        m.statusCodes.getAppTs.Code,
 			Description: "Unable to get application from temporary store",
+			Remediation: "Retry the request",
 			Attributes:  []string{"retry"},
 		},
 		{
 			Name:        m.statusCodes.errSaveAppPs.Name,
 			Code:        m.statusCodes.errSaveAppPs.Code,
 			Description: "Unable to save application to primary store",
+			Remediation: "Retry the deploy, check that metakv is reachable and the function isn't too large",
 		},
 		{
 			Name:        m.statusCodes.errSaveAppTs.Name,
 			Code:        m.statusCodes.errSaveAppTs.Code,
 			Description: "Unable to save application to temporary store",
+			Remediation: "Retry the save",
 			Attributes:  []string{"retry"},
 		},
 		{
 			Name:        m.statusCodes.errSetSettingsPs.Name,
 			Code:        m.statusCodes.errSetSettingsPs.Code,
 			Description: "Unable to set application settings in primary store",
+			Remediation: "Retry the settings change, check that metakv is reachable",
 		},
 		{
 			Name:        m.statusCodes.errDelAppSettingsPs.Name,
 			Code:        m.statusCodes.errDelAppSettingsPs.Code,
 			Description: "Unable to delete app settings",
+			Remediation: "Retry the delete, check that metakv is reachable",
 		},
 		{
 			Name:        m.statusCodes.errAppNotDeployed.Name,
 			Code:        m.statusCodes.errAppNotDeployed.Code,
 			Description: "Application not deployed",
+			Remediation: "Deploy the function before using this endpoint",
 		},
 		{
 			Name:        m.statusCodes.errAppNotFoundTs.Name,
 			Code:        m.statusCodes.errAppNotFoundTs.Code,
 			Description: "Application not found in temporary store",
+			Remediation: "Check the function name, list functions with GET /api/v1/functions",
 		},
 		{
 			Name:        m.statusCodes.errMarshalResp.Name,
 			Code:        m.statusCodes.errMarshalResp.Code,
 			Description: "Unable to marshal response",
+			Remediation: "Retry the request, report the error if it persists",
 		},
 		{
 			Name:        m.statusCodes.errReadReq.Name,
 			Code:        m.statusCodes.errReadReq.Code,
 			Description: "Unable to read the request body",
+			Remediation: "Resend the request with a complete body",
 		},
 		{
 			Name:        m.statusCodes.errUnmarshalPld.Name,
 			Code:        m.statusCodes.errUnmarshalPld.Code,
 			Description: "Unable to unmarshal payload",
+			Remediation: "Send a well formed JSON body matching the documented schema",
 		},
 		{
 			Name:        m.statusCodes.errSrcMbSame.Name,
 			Code:        m.statusCodes.errSrcMbSame.Code,
 			Description: "Source bucket same as metadata bucket",
+			Remediation: "Use different buckets as source and metadata buckets",
 		},
 		{
 			Name:        m.statusCodes.errInvalidExt.Name,
 			Code:        m.statusCodes.errInvalidExt.Code,
 			Description: "Invalid file extension",
+			Remediation: "Request a .js or .map file",
 		},
 		{
 			Name:        m.statusCodes.errGetVbSeqs.Name,
 			Code:        m.statusCodes.errGetVbSeqs.Code,
 			Description: "Failed to fetch vb sequence processed so far",
+			Remediation: "Retry the request once the function has finished bootstrapping",
 		},
 		{
 			Name:        m.statusCodes.errAppDeployed.Name,
 			Code:        m.statusCodes.errAppDeployed.Code,
 			Description: "App is already deployed",
+			Remediation: "Undeploy the function before making this change",
 		},
 		{
 			Name:        m.statusCodes.errAppNotInit.Name,
 			Code:        m.statusCodes.errAppNotInit.Code,
 			Description: "App hasn't bootstrapped",
+			Remediation: "Wait for the function to finish bootstrapping and retry",
 		},
 		{
 			Name:        m.statusCodes.errAppNotUndeployed.Name,
 			Code:        m.statusCodes.errAppNotUndeployed.Code,
 			Description: "App hasn't been undeployed",
+			Remediation: "Undeploy the function and wait for undeploy to finish before retrying",
 		},
 		{
 			Name:        m.statusCodes.errStatusesNotFound.Name,
 			Code:        m.statusCodes.errStatusesNotFound.Code,
 			Description: "Processing or deployment status or both missing from supplied settings",
+			Remediation: "Provide both deployment_status and processing_status in settings",
 		},
 		{
 			Name:        m.statusCodes.errConnectNsServer.Name,
 			Code:        m.statusCodes.errConnectNsServer.Code,
 			Description: "Failed to connect to cluster manager",
+			Remediation: "Check that the cluster manager is running and retry",
 		},
 		{
 			Name:        m.statusCodes.errBucketTypeCheck.Name,
 			Code:        m.statusCodes.errBucketTypeCheck.Code,
 			Description: "Failed to check type of source bucket",
+			Remediation: "Check that the source bucket exists and retry",
 		},
 		{
 			Name:        m.statusCodes.errMemcachedBucket.Name,
 			Code:        m.statusCodes.errMemcachedBucket.Code,
 			Description: "Source bucket can't be of type memcached",
+			Remediation: "Use a couchbase or ephemeral bucket as source bucket",
 		},
 		{
 			Name:        m.statusCodes.errHandlerCompile.Name,
 			Code:        m.statusCodes.errHandlerCompile.Code,
 			Description: "Handler compilation failed",
+			Remediation: "Fix the compilation errors reported in the handler code",
 		},
 		{
 			Name:        m.statusCodes.errRbacCreds.Name,
 			Code:        m.statusCodes.errRbacCreds.Code,
 			Description: "RBAC username/password missing",
+			Remediation: "Provide RBAC username and password",
 		},
 		{
 			Name:        m.statusCodes.errAppNameMismatch.Name,
 			Code:        m.statusCodes.errAppNameMismatch.Code,
 			Description: "Function names must be same",
+			Remediation: "Use the same function name in the URL and in the request body",
 		},
 		{
 			Name:        m.statusCodes.errSrcBucketMissing.Name,
 			Code:        m.statusCodes.errSrcBucketMissing.Code,
 			Description: "Source bucket missing",
+			Remediation: "Create the source bucket or pick an existing one",
 		},
 		{
 			Name:        m.statusCodes.errMetaBucketMissing.Name,
 			Code:        m.statusCodes.errMetaBucketMissing.Code,
 			Description: "Metadata bucket missing",
+			Remediation: "Create the metadata bucket or pick an existing one",
 		},
 		{
 			Name:        m.statusCodes.errNoEventingNodes.Name,
 			Code:        m.statusCodes.errNoEventingNodes.Code,
 			Description: "No eventing reported from cluster manager",
+			Remediation: "Add a node running the eventing service to the cluster",
 		},
 		{
 			Name:        m.statusCodes.errSaveConfig.Name,
 			Code:        m.statusCodes.errSaveConfig.Code,
 			Description: "Failed to save config to metakv",
+			Remediation: "Retry the config change, check that metakv is reachable",
 		},
 		{
 			Name:        m.statusCodes.errGetConfig.Name,
 			Code:        m.statusCodes.errGetConfig.Code,
 			Description: "Failed to get config from metakv",
+			Remediation: "Retry the request, check that metakv is reachable",
 		},
 		{
 			Name:        m.statusCodes.errGetCreds.Name,
 			Code:        m.statusCodes.errGetCreds.Code,
 			Description: "Failed to get credentials from cbauth",
+			Remediation: "Retry the request",
 		},
 		{
 			Name:        m.statusCodes.errGetRebStatus.Name,
 			Code:        m.statusCodes.errGetRebStatus.Code,
 			Description: "Failed to get rebalance status from eventing nodes",
+			Remediation: "Retry the request, check that all eventing nodes are reachable",
 		},
 		{
 			Name:        m.statusCodes.errRebOngoing.Name,
 			Code:        m.statusCodes.errRebOngoing.Code,
 			Description: "Rebalance ongoing on some/all Eventing nodes, creating new apps or changing settings for existing apps isn't allowed",
+			Remediation: "Wait for rebalance to finish and retry",
 		},
 		{
 			Name:        m.statusCodes.errActiveEventingNodes.Name,
 			Code:        m.statusCodes.errActiveEventingNodes.Code,
 			Description: "Failed to fetch active Eventing nodes",
+			Remediation: "Retry the request, check that the cluster manager is reachable",
 		},
 		{
 			Name:        m.statusCodes.errInvalidConfig.Name,
 			Code:        m.statusCodes.errInvalidConfig.Code,
 			Description: "Invalid configuration",
+			Remediation: "Correct the value named in field, GET /api/v1/settings/schema lists valid settings",
 		},
 		{
 			Name:        m.statusCodes.errAppCodeSize.Name,
 			Code:        m.statusCodes.errAppCodeSize.Code,
 			Description: "Handler Code size is more than 128k",
+			Remediation: "Reduce the size of the handler code",
+		},
+		{
+			Name:        m.statusCodes.errRevisionNotFound.Name,
+			Code:        m.statusCodes.errRevisionNotFound.Code,
+			Description: "Function revision not found",
+			Remediation: "List available revisions with GET /api/v1/functions/<name>/revisions",
+		},
+		{
+			Name:        m.statusCodes.errSaveRevision.Name,
+			Code:        m.statusCodes.errSaveRevision.Code,
+			Description: "Unable to save function revision",
+			Remediation: "Retry the save",
+			Attributes:  []string{"retry"},
+		},
+		{
+			Name:        m.statusCodes.errFunctionExists.Name,
+			Code:        m.statusCodes.errFunctionExists.Code,
+			Description: "Function with same name already exists",
+			Remediation: "Pick another function name, or choose on_conflict=skip or rename on import",
+		},
+		{
+			Name:        m.statusCodes.errImportAborted.Name,
+			Code:        m.statusCodes.errImportAborted.Code,
+			Description: "Import aborted as some functions in bundle failed validation",
+			Remediation: "Fix the functions reported as failed in the bundle and import again",
+		},
+		{
+			Name:        m.statusCodes.errAppPaused.Name,
+			Code:        m.statusCodes.errAppPaused.Code,
+			Description: "Function is already paused",
+			Remediation: "Resume the function, or leave it paused",
+		},
+		{
+			Name:        m.statusCodes.errAppNotPaused.Name,
+			Code:        m.statusCodes.errAppNotPaused.Code,
+			Description: "Function is not paused",
+			Remediation: "Pause the function before resuming it",
+		},
+		{
+			Name:        m.statusCodes.errInvalidN1QL.Name,
+			Code:        m.statusCodes.errInvalidN1QL.Code,
+			Description: "Invalid N1QL query in handler code",
+			Remediation: "Fix the N1QL query reported in the handler code",
+		},
+		{
+			Name:        m.statusCodes.errAppValidation.Name,
+			Code:        m.statusCodes.errAppValidation.Code,
+			Description: "Function failed pre-deployment validation",
+			Remediation: "Fix the failed checks listed in the response",
+		},
+		{
+			Name:        m.statusCodes.errGetAppLog.Name,
+			Code:        m.statusCodes.errGetAppLog.Code,
+			Description: "Failed to get function's application log from eventing nodes",
+			Remediation: "Retry the request, check that all eventing nodes are reachable",
+		},
+		{
+			Name:        m.statusCodes.errPreconditionFailed.Name,
+			Code:        m.statusCodes.errPreconditionFailed.Code,
+			Description: "Function or its settings changed since they were read, If-Match doesn't match current ETag",
+			Remediation: "Fetch the function again and retry with its current ETag",
+		},
+		{
+			Name:        m.statusCodes.errMutationCycle.Name,
+			Code:        m.statusCodes.errMutationCycle.Code,
+			Description: "Deploying function would form a cycle of functions mutating each other's source bucket",
+			Remediation: "Change the source bucket or the buckets written to, so that functions don't mutate each other's source bucket",
+		},
+		{
+			Name:        m.statusCodes.errUnauthenticated.Name,
+			Code:        m.statusCodes.errUnauthenticated.Code,
+			Description: "Request carries no valid credentials",
+			Remediation: "Provide valid credentials",
+		},
+		{
+			Name:        m.statusCodes.errForbidden.Name,
+			Code:        m.statusCodes.errForbidden.Code,
+			Description: "Credentials don't hold the permission needed for the request",
+			Remediation: "Use credentials holding the eventing permission needed for this request",
+		},
+		{
+			Name:        m.statusCodes.errMethodNotAllowed.Name,
+			Code:        m.statusCodes.errMethodNotAllowed.Code,
+			Description: "HTTP method isn't supported by the endpoint",
+			Remediation: "Use one of the HTTP methods documented for this endpoint",
 		},
 	}
 
 	m.errorCodes = make(map[int]errorPayload)
-	for _, err := range errors {
-		m.errorCodes[err.Code] = err
+	for i := range errors {
+		errors[i].HTTPStatus = m.getDisposition(errors[i].Code)
+		m.errorCodes[errors[i].Code] = errors[i]
 	}
 
 	statusPayload := statusPayload{
 		HeaderKey: headerKey,
 		Version:   1,
-		Revision:  1,
+		Revision:  2,
 		Errors:    errors,
 	}
 
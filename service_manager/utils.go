@@ -33,65 +33,6 @@ func encodeRev(rev uint64) service.Revision {
 	return ext
 }
 
-func fillMissingWithDefaults(settings map[string]interface{}) {
-	// Handler related configurations
-	fillMissingDefault(settings, "checkpoint_interval", float64(60000))
-	fillMissingDefault(settings, "cpp_worker_thread_count", float64(2))
-	fillMissingDefault(settings, "cron_timers_per_doc", float64(1000))
-	fillMissingDefault(settings, "curl_timeout", float64(500))
-	fillMissingDefault(settings, "deadline_timeout", float64(4))
-	fillMissingDefault(settings, "execution_timeout", float64(2))
-	fillMissingDefault(settings, "enable_recursive_mutation", false)
-	fillMissingDefault(settings, "execution_timeout", float64(1))
-	fillMissingDefault(settings, "feedback_batch_size", float64(100))
-	fillMissingDefault(settings, "feedback_read_buffer_size", float64(65536))
-	fillMissingDefault(settings, "fuzz_offset", float64(0))
-	fillMissingDefault(settings, "lcb_inst_capacity", float64(5))
-	fillMissingDefault(settings, "log_level", "INFO")
-	fillMissingDefault(settings, "skip_timer_threshold", float64(86400))
-	fillMissingDefault(settings, "sock_batch_size", float64(100))
-	fillMissingDefault(settings, "tick_duration", float64(60000))
-	fillMissingDefault(settings, "timer_processing_tick_interval", float64(500))
-	fillMissingDefault(settings, "worker_count", float64(3))
-	fillMissingDefault(settings, "worker_feedback_queue_cap", float64(10*1000))
-	fillMissingDefault(settings, "worker_queue_cap", float64(100*1000))
-	fillMissingDefault(settings, "xattr_doc_timer_entry_prune_threshold", float64(100))
-
-	// Process related configuration
-	fillMissingDefault(settings, "breakpad_on", false)
-
-	// Rebalance related configurations
-	fillMissingDefault(settings, "vb_ownership_giveup_routine_count", float64(3))
-	fillMissingDefault(settings, "vb_ownership_takeover_routine_count", float64(3))
-
-	// Application logging related configurations
-	fillMissingDefault(settings, "app_log_max_size", float64(1024*1024*10))
-	fillMissingDefault(settings, "app_log_max_files", float64(10))
-
-	// Doc timer configurations for plasma
-	fillMissingDefault(settings, "auto_swapper", true)
-	fillMissingDefault(settings, "enable_snapshot_smr", false)
-	fillMissingDefault(settings, "lss_cleaner_max_threshold", float64(70))
-	fillMissingDefault(settings, "lss_cleaner_threshold", float64(30))
-	fillMissingDefault(settings, "lss_read_ahead_size", float64(1024*1024))
-	fillMissingDefault(settings, "max_delta_chain_len", float64(200))
-	fillMissingDefault(settings, "max_page_items", float64(400))
-	fillMissingDefault(settings, "min_page_items", float64(50))
-	fillMissingDefault(settings, "persist_interval", float64(5000))
-	fillMissingDefault(settings, "use_memory_manager", true)
-
-	// DCP connection related configurations
-	fillMissingDefault(settings, "data_chan_size", float64(10000))
-	fillMissingDefault(settings, "dcp_gen_chan_size", float64(10000))
-	fillMissingDefault(settings, "dcp_num_connections", float64(1))
-}
-
-func fillMissingDefault(settings map[string]interface{}, field string, defaultValue interface{}) {
-	if _, ok := settings[field]; !ok {
-		settings[field] = defaultValue
-	}
-}
-
 func (m *ServiceMgr) getHandler(appName string) string {
 	if m.checkIfDeployed(appName) {
 		return m.superSup.GetHandlerCode(appName)
@@ -109,8 +50,17 @@ func (m *ServiceMgr) getSourceMap(appName string) string {
 }
 
 func (m *ServiceMgr) sendErrorInfo(w http.ResponseWriter, runtimeInfo *runtimeInfo) {
+	if runtimeInfo.Code != m.statusCodes.ok.Code && m.errorFormat() == errorFormatV2 {
+		m.sendErrorEnvelope(w, runtimeInfo)
+		return
+	}
+
 	errInfo := m.errorCodes[runtimeInfo.Code]
 	errInfo.RuntimeInfo = runtimeInfo.Info
+
+	// Success and v1 errors are sent as they were before v2 envelope
+	errInfo.HTTPStatus = 0
+	errInfo.Remediation = ""
 	response, err := json.Marshal(errInfo)
 	if err != nil {
 		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errMarshalResp.Code))
@@ -172,8 +122,7 @@ func (m *ServiceMgr) unmarshalAppList(w http.ResponseWriter, r *http.Request) []
 	if err != nil {
 		errString := fmt.Sprintf("Failed to read request body, err: %v", err)
 		logging.Errorf("%s %s", logPrefix, errString)
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errReadReq.Code))
-		fmt.Fprintf(w, errString)
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errReadReq.Code, Info: errString})
 		return nil
 	}
 
@@ -182,8 +131,7 @@ func (m *ServiceMgr) unmarshalAppList(w http.ResponseWriter, r *http.Request) []
 	if err != nil {
 		errString := fmt.Sprintf("Failed to unmarshal payload err: %v", err)
 		logging.Errorf("%s %s", logPrefix, errString)
-		w.Header().Add(headerKey, strconv.Itoa(m.statusCodes.errUnmarshalPld.Code))
-		fmt.Fprintf(w, "%s\n", errString)
+		m.sendPlainErrorInfo(w, &runtimeInfo{Code: m.statusCodes.errUnmarshalPld.Code, Info: errString})
 		return nil
 	}
 
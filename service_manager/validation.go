@@ -10,15 +10,22 @@ import (
 	"strings"
 
 	"github.com/couchbase/cbauth"
+	"github.com/couchbase/eventing/common"
 	"github.com/couchbase/eventing/logging"
 	"github.com/couchbase/eventing/util"
 )
 
+var appNameRegex = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_-]*$")
+
+// Names taken by routes under /api/v1/functions/, which a function of that name would be shadowed by
+var reservedAppNames = []string{"graph", "settings"}
+
 func (m *ServiceMgr) validateApplication(app *application) (info *runtimeInfo) {
 	info = &runtimeInfo{}
 	info.Code = m.statusCodes.errInvalidConfig.Code
 
 	if info = m.validateApplicationName(app.Name); info.Code != m.statusCodes.ok.Code {
+		info.Field = "appname"
 		return
 	}
 
@@ -27,6 +34,7 @@ func (m *ServiceMgr) validateApplication(app *application) (info *runtimeInfo) {
 	}
 
 	if info = m.validateNonEmpty(app.AppHandlers, "Function handler"); info.Code != m.statusCodes.ok.Code {
+		info.Field = "appcode"
 		return
 	}
 
@@ -34,25 +42,50 @@ func (m *ServiceMgr) validateApplication(app *application) (info *runtimeInfo) {
 		return
 	}
 
+	if info = m.validateLabels(app.Labels); info.Code != m.statusCodes.ok.Code {
+		return
+	}
+
 	info.Code = m.statusCodes.ok.Code
 	return
 }
 
+// Request is allowed if the user holds perm, manage permission implies every other
+// eventing permission
 func (m *ServiceMgr) validateAuth(w http.ResponseWriter, r *http.Request, perm string) bool {
 	creds, err := cbauth.AuthWebCreds(r)
 	if err != nil || creds == nil {
 		logging.Warnf("Cannot authenticate request to %rs", r.URL)
-		w.WriteHeader(http.StatusUnauthorized)
+		m.sendAuthError(w, m.statusCodes.errUnauthenticated.Code, "Request carries no valid credentials")
 		return false
 	}
-	allowed, err := creds.IsAllowed(perm)
-	if err != nil || !allowed {
-		logging.Warnf("Cannot authorize request to %rs", r.URL)
-		w.WriteHeader(http.StatusForbidden)
-		return false
+
+	for _, p := range eventingPermissions(perm) {
+		allowed, err := creds.IsAllowed(p)
+		if err == nil && allowed {
+			logging.Debugf("Allowing access to %rs", r.URL)
+			return true
+		}
 	}
-	logging.Debugf("Allowing access to %rs", r.URL)
-	return true
+
+	logging.Warnf("Cannot authorize request to %rs", r.URL)
+	m.sendAuthError(w, m.statusCodes.errForbidden.Code, fmt.Sprintf("Request needs permission %s", perm))
+	return false
+}
+
+func eventingPermissions(perm string) []string {
+	if perm == EventingPermissionManage {
+		return []string{perm}
+	}
+	return []string{perm, EventingPermissionManage}
+}
+
+// Reads need read permission, every other request needs manage permission
+func methodPermission(r *http.Request) string {
+	if r.Method == "GET" {
+		return EventingPermissionRead
+	}
+	return EventingPermissionManage
 }
 
 func (m *ServiceMgr) validateAliasName(aliasName string) (info *runtimeInfo) {
@@ -179,13 +212,18 @@ func (m *ServiceMgr) validateApplicationName(applicationName string) (info *runt
 		return
 	}
 
-	appNameRegex := regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_-]*$")
 	if !appNameRegex.MatchString(applicationName) {
 		info.Code = m.statusCodes.errInvalidConfig.Code
 		info.Info = "Function name can only contain characters in range A-Z, a-z, 0-9 and underscore, hyphen"
 		return
 	}
 
+	if util.Contains(applicationName, reservedAppNames) {
+		info.Code = m.statusCodes.errInvalidConfig.Code
+		info.Info = fmt.Sprintf("Function name must not be one of %s", strings.Join(reservedAppNames, ", "))
+		return
+	}
+
 	info.Code = m.statusCodes.ok.Code
 	return
 }
@@ -205,24 +243,69 @@ func (m *ServiceMgr) validateBoolean(field string, settings map[string]interface
 	return
 }
 
+// Checks that source and metadata buckets exist and source bucket isn't memcached
+func (m *ServiceMgr) validateBuckets(deploymentConfig *depCfg) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+
+	nsServerEndpoint := net.JoinHostPort(util.Localhost(), m.restPort)
+	cinfo, err := util.FetchNewClusterInfoCache(nsServerEndpoint)
+	if err != nil {
+		info.Code = m.statusCodes.errConnectNsServer.Code
+		info.Info = fmt.Sprintf("Failed to initialise cluster info cache, err: %v", err)
+		return
+	}
+
+	if cinfo.GetBucketUUID(deploymentConfig.SourceBucket) == "" {
+		info.Code = m.statusCodes.errSrcBucketMissing.Code
+		info.Info = fmt.Sprintf("Supplied source bucket: %v doesn't exist", deploymentConfig.SourceBucket)
+		return
+	}
+
+	if cinfo.GetBucketUUID(deploymentConfig.MetadataBucket) == "" {
+		info.Code = m.statusCodes.errMetaBucketMissing.Code
+		info.Info = fmt.Sprintf("Supplied metadata bucket: %v doesn't exist", deploymentConfig.MetadataBucket)
+		return
+	}
+
+	isMemcached, err := cinfo.IsMemcached(deploymentConfig.SourceBucket)
+	if err != nil {
+		info.Code = m.statusCodes.errBucketTypeCheck.Code
+		info.Info = fmt.Sprintf("Failed to check bucket type using cluster info cache, err: %v", err)
+		return
+	}
+
+	if isMemcached {
+		info.Code = m.statusCodes.errMemcachedBucket.Code
+		info.Info = "Source bucket is memcached, should be either couchbase or ephemeral"
+		return
+	}
+
+	info.Code = m.statusCodes.ok.Code
+	return
+}
+
 func (m *ServiceMgr) validateDeploymentConfig(deploymentConfig *depCfg) (info *runtimeInfo) {
 	info = &runtimeInfo{}
 	info.Code = m.statusCodes.errInvalidConfig.Code
 
 	if info = m.validateNonEmpty(deploymentConfig.SourceBucket, "Source bucket name"); info.Code != m.statusCodes.ok.Code {
+		info.Field = "depcfg.source_bucket"
 		return
 	}
 
 	if info = m.validateNonEmpty(deploymentConfig.MetadataBucket, "Metadata bucket name"); info.Code != m.statusCodes.ok.Code {
+		info.Field = "depcfg.metadata_bucket"
 		return
 	}
 
-	for _, bucket := range deploymentConfig.Buckets {
+	for i, bucket := range deploymentConfig.Buckets {
 		if info = m.validateNonEmpty(bucket.BucketName, "Alias bucket name"); info.Code != m.statusCodes.ok.Code {
+			info.Field = fmt.Sprintf("depcfg.buckets[%d].bucket_name", i)
 			return
 		}
 
 		if info = m.validateAliasName(bucket.Alias); info.Code != m.statusCodes.ok.Code {
+			info.Field = fmt.Sprintf("depcfg.buckets[%d].alias", i)
 			return
 		}
 	}
@@ -284,28 +367,28 @@ func (m *ServiceMgr) validateLocalAuth(w http.ResponseWriter, r *http.Request) b
 	ip, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
 		logging.Warnf("Unable to verify remote in request to %rs: %rs", r.URL, err)
-		w.WriteHeader(http.StatusForbidden)
+		m.sendAuthError(w, m.statusCodes.errForbidden.Code, "Unable to verify remote address of request")
 		return false
 	}
 
 	pip := net.ParseIP(ip)
 	if pip == nil || !pip.IsLoopback() {
 		logging.Warnf("Forbidden remote in request to %rs: %rs", r.URL, r)
-		w.WriteHeader(http.StatusForbidden)
+		m.sendAuthError(w, m.statusCodes.errForbidden.Code, "Request must come from the local node")
 		return false
 	}
 
 	rUsr, rKey, ok := r.BasicAuth()
 	if !ok {
 		logging.Warnf("No credentials on request to %rs", r.URL)
-		w.WriteHeader(http.StatusForbidden)
+		m.sendAuthError(w, m.statusCodes.errForbidden.Code, "Request carries no credentials")
 		return false
 	}
 
 	usr, key := util.LocalKey()
 	if rUsr != usr || rKey != key {
 		logging.Warnf("Cannot authorize request to %rs", r.URL)
-		w.WriteHeader(http.StatusForbidden)
+		m.sendAuthError(w, m.statusCodes.errForbidden.Code, "Request carries invalid local credentials")
 		return false
 	}
 
@@ -385,213 +468,135 @@ func (m *ServiceMgr) validatePositiveInteger(field string, settings map[string]i
 	return
 }
 
-func (m *ServiceMgr) validatePossibleValues(field string, settings map[string]interface{}, possibleValues []string) (info *runtimeInfo) {
+func (m *ServiceMgr) validateKeyFilters(field string, settings map[string]interface{}) (info *runtimeInfo) {
 	info = &runtimeInfo{}
 	info.Code = m.statusCodes.errInvalidConfig.Code
 
-	if val, ok := settings[field]; ok && !util.Contains(val.(string), possibleValues) {
-		info.Info = fmt.Sprintf("Invalid value for %s, possible values are %s", field, strings.Join(possibleValues, ", "))
-		return
+	if val, ok := settings[field]; ok {
+		if _, err := common.ParseKeyFilters(val); err != nil {
+			info.Info = fmt.Sprintf("%s %v", field, err)
+			return
+		}
 	}
 
 	info.Code = m.statusCodes.ok.Code
 	return
 }
 
-func (m *ServiceMgr) validateSettings(settings map[string]interface{}) (info *runtimeInfo) {
+func (m *ServiceMgr) validateN1QLExpr(field string, settings map[string]interface{}) (info *runtimeInfo) {
 	info = &runtimeInfo{}
 	info.Code = m.statusCodes.errInvalidConfig.Code
 
-	fillMissingWithDefaults(settings)
-
-	// Handler related configurations
-	if info = m.validateBoolean("processing_status", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validateBoolean("deployment_status", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("checkpoint_interval", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validateBoolean("cleanup_timers", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("cpp_worker_thread_count", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("cron_timers_per_doc", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("curl_timeout", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePossibleValues("dcp_stream_boundary", settings, []string{"everything", "from_now"}); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("deadline_timeout", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validateBoolean("enable_recursive_mutation", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("execution_timeout", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validateLessThan("execution_timeout", "deadline_timeout", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("feedback_batch_size", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("feedback_read_buffer_size", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validateZeroOrPositiveInteger("fuzz_offset", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("lcb_inst_capacity", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePossibleValues("log_level", settings, []string{"INFO", "ERROR", "WARNING", "DEBUG", "TRACE"}); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("skip_timer_threshold", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("sock_batch_size", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("tick_duration", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("timer_processing_tick_interval", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("worker_count", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
-
-	if info = m.validatePositiveInteger("worker_feedback_queue_cap", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	if val, ok := settings[field]; ok {
+		expr, isString := val.(string)
+		if !isString {
+			info.Info = fmt.Sprintf("%s must be a string", field)
+			return
+		}
 
-	if info = m.validatePositiveInteger("worker_queue_cap", settings); info.Code != m.statusCodes.ok.Code {
-		return
+		if _, err := util.NewSourceFilter(expr); err != nil {
+			info.Info = fmt.Sprintf("%s %v", field, err)
+			return
+		}
 	}
 
-	if info = m.validatePositiveInteger("xattr_doc_timer_entry_prune_threshold", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	info.Code = m.statusCodes.ok.Code
+	return
+}
 
-	// Process related configuration
-	if info = m.validateBoolean("breakpad_on", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+func (m *ServiceMgr) validateStringList(field string, settings map[string]interface{}) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+	info.Code = m.statusCodes.errInvalidConfig.Code
 
-	// Rebalance related configurations
-	if info = m.validatePositiveInteger("vb_ownership_giveup_routine_count", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	if val, ok := settings[field]; ok {
+		list, isList := val.([]interface{})
+		if !isList {
+			info.Info = fmt.Sprintf("%s must be a list of strings", field)
+			return
+		}
 
-	if info = m.validatePositiveInteger("vb_ownership_takeover_routine_count", settings); info.Code != m.statusCodes.ok.Code {
-		return
+		for i, item := range list {
+			if s, isString := item.(string); !isString || s == "" {
+				info.Info = fmt.Sprintf("%s[%d] must be a non-empty string", field, i)
+				return
+			}
+		}
 	}
 
-	// Application logging related configurations
-	if info = m.validateDirPath("app_log_dir", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	info.Code = m.statusCodes.ok.Code
+	return
+}
 
-	if info = m.validatePositiveInteger("app_log_max_size", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+func (m *ServiceMgr) validatePossibleValues(field string, settings map[string]interface{}, possibleValues []string) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+	info.Code = m.statusCodes.errInvalidConfig.Code
 
-	if info = m.validatePositiveInteger("app_log_max_files", settings); info.Code != m.statusCodes.ok.Code {
-		return
+	if val, ok := settings[field]; ok {
+		if str, isString := val.(string); !isString || !util.Contains(str, possibleValues) {
+			info.Info = fmt.Sprintf("Invalid value for %s, possible values are %s", field, strings.Join(possibleValues, ", "))
+			return
+		}
 	}
 
-	// Doc timer configurations for plasma
-	if info = m.validateBoolean("auto_swapper", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	info.Code = m.statusCodes.ok.Code
+	return
+}
 
-	if info = m.validateBoolean("enable_snapshot_smr", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+func (m *ServiceMgr) validateSettings(settings map[string]interface{}) (info *runtimeInfo) {
+	info = &runtimeInfo{}
+	info.Code = m.statusCodes.errInvalidConfig.Code
 
-	if info = m.validatePositiveInteger("iterator_refresh_counter", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	common.FillMissingSettings(settings)
 
-	if info = m.validatePositiveInteger("lss_cleaner_max_threshold", settings); info.Code != m.statusCodes.ok.Code {
-		return
+	for _, schema := range common.SettingsSchema {
+		if info = m.validateSetting(schema, settings); info.Code != m.statusCodes.ok.Code {
+			info.Field = "settings." + schema.Name
+			return
+		}
 	}
 
-	if info = m.validatePositiveInteger("lss_cleaner_threshold", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	info.Code = m.statusCodes.ok.Code
+	return
+}
 
-	if info = m.validatePositiveInteger("lss_read_ahead_size", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+func (m *ServiceMgr) validateSetting(schema common.SettingSchema, settings map[string]interface{}) (info *runtimeInfo) {
+	switch schema.Type {
+	case common.SettingTypeBoolean:
+		info = m.validateBoolean(schema.Name, settings)
 
-	if info = m.validatePositiveInteger("max_delta_chain_len", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	case common.SettingTypeDirPath:
+		info = m.validateDirPath(schema.Name, settings)
 
-	if info = m.validatePositiveInteger("max_page_items", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	case common.SettingTypeInteger:
+		if schema.Minimum != nil && *schema.Minimum == 0 {
+			info = m.validateZeroOrPositiveInteger(schema.Name, settings)
+		} else {
+			info = m.validatePositiveInteger(schema.Name, settings)
+		}
 
-	if info = m.validatePositiveInteger("min_page_items", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	case common.SettingTypeKeyFilters:
+		info = m.validateKeyFilters(schema.Name, settings)
 
-	if info = m.validatePositiveInteger("persist_interval", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	case common.SettingTypeN1QLExpr:
+		info = m.validateN1QLExpr(schema.Name, settings)
 
-	if info = m.validateBoolean("use_memory_manager", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	case common.SettingTypeString:
+		info = m.validatePossibleValues(schema.Name, settings, schema.PossibleValues)
 
-	// DCP connection related configurations
-	if info = m.validatePositiveInteger("data_chan_size", settings); info.Code != m.statusCodes.ok.Code {
-		return
-	}
+	case common.SettingTypeStringList:
+		info = m.validateStringList(schema.Name, settings)
 
-	if info = m.validatePositiveInteger("dcp_gen_chan_size", settings); info.Code != m.statusCodes.ok.Code {
-		return
+	default:
+		info = &runtimeInfo{}
+		info.Code = m.statusCodes.errInvalidConfig.Code
+		info.Info = fmt.Sprintf("Setting %s has unknown type %s", schema.Name, schema.Type)
 	}
 
-	if info = m.validatePositiveInteger("dcp_num_connections", settings); info.Code != m.statusCodes.ok.Code {
+	if info.Code != m.statusCodes.ok.Code || schema.LessThan == "" {
 		return
 	}
 
-	info.Code = m.statusCodes.ok.Code
-	return
+	return m.validateLessThan(schema.Name, schema.LessThan, settings)
 }
 
 func (m *ServiceMgr) validateZeroOrPositiveInteger(field string, settings map[string]interface{}) (info *runtimeInfo) {
@@ -0,0 +1,96 @@
+package servicemanager
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/couchbase/eventing/common"
+)
+
+func TestEventingPermissions(t *testing.T) {
+	tests := []struct {
+		name string
+		perm string
+		want []string
+	}{
+		{"manage", EventingPermissionManage, []string{EventingPermissionManage}},
+		{"read implied by manage", EventingPermissionRead, []string{EventingPermissionRead, EventingPermissionManage}},
+		{"stats implied by manage", EventingPermissionStats, []string{EventingPermissionStats, EventingPermissionManage}},
+	}
+
+	for _, test := range tests {
+		if got := eventingPermissions(test.perm); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMethodPermission(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{"GET", EventingPermissionRead},
+		{"POST", EventingPermissionManage},
+		{"DELETE", EventingPermissionManage},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, "/api/v1/functions", nil)
+		if got := methodPermission(r); got != test.want {
+			t.Errorf("%s: got %s want %s", test.method, got, test.want)
+		}
+	}
+}
+
+func TestValidateApplicationName(t *testing.T) {
+	m := &ServiceMgr{}
+	m.initErrCodes()
+
+	tests := []struct {
+		name    string
+		appName string
+		want    int
+	}{
+		{"valid", "orders_enrich-1", m.statusCodes.ok.Code},
+		{"empty", "", m.statusCodes.errInvalidConfig.Code},
+		{"invalid character", "orders.enrich", m.statusCodes.errInvalidConfig.Code},
+		{"reserved for graph", "graph", m.statusCodes.errInvalidConfig.Code},
+		{"reserved for bulk settings", "settings", m.statusCodes.errInvalidConfig.Code},
+		{"reserved name as prefix", "graph_1", m.statusCodes.ok.Code},
+	}
+
+	for _, test := range tests {
+		if got := m.validateApplicationName(test.appName); got.Code != test.want {
+			t.Errorf("%s: got %d want %d", test.name, got.Code, test.want)
+		}
+	}
+}
+
+func TestValidateSetting(t *testing.T) {
+	m := &ServiceMgr{}
+	m.initErrCodes()
+
+	logLevel := common.SettingSchema{Name: "log_level", Type: common.SettingTypeString, PossibleValues: []string{"INFO", "TRACE"}}
+
+	tests := []struct {
+		name     string
+		schema   common.SettingSchema
+		settings map[string]interface{}
+		want     int
+	}{
+		{"possible value", logLevel, map[string]interface{}{"log_level": "TRACE"}, m.statusCodes.ok.Code},
+		{"not set", logLevel, map[string]interface{}{}, m.statusCodes.ok.Code},
+		{"impossible value", logLevel, map[string]interface{}{"log_level": "VERBOSE"}, m.statusCodes.errInvalidConfig.Code},
+		{"not a string", logLevel, map[string]interface{}{"log_level": float64(5)}, m.statusCodes.errInvalidConfig.Code},
+		{"unknown type", common.SettingSchema{Name: "new_setting", Type: common.SettingType("duration")},
+			map[string]interface{}{"new_setting": "5s"}, m.statusCodes.errInvalidConfig.Code},
+	}
+
+	for _, test := range tests {
+		if got := m.validateSetting(test.schema, test.settings); got.Code != test.want {
+			t.Errorf("%s: got %d want %d", test.name, got.Code, test.want)
+		}
+	}
+}
@@ -33,6 +33,14 @@ func (s *SuperSupervisor) GetEventProcessingStats(appName string) map[string]uin
 	return nil
 }
 
+// GetEventsProcessedPSec returns dcp/timer events processed per sec
+func (s *SuperSupervisor) GetEventsProcessedPSec(appName string) *common.EventProcessingStats {
+	if p, ok := s.runningProducers[appName]; ok {
+		return p.GetEventsProcessedPSec()
+	}
+	return nil
+}
+
 // GetAppCode returns handler code for requested appname
 func (s *SuperSupervisor) GetAppCode(appName string) string {
 	logPrefix := "SuperSupervisor::GetAppCode"
@@ -44,6 +52,16 @@ func (s *SuperSupervisor) GetAppCode(appName string) string {
 	return ""
 }
 
+// GetAppLog returns the last sz lines of application log written on this node
+func (s *SuperSupervisor) GetAppLog(appName string, sz int) ([]string, error) {
+	p, ok := s.runningProducers[appName]
+	if ok {
+		return p.GetAppLog(sz)
+	}
+
+	return nil, fmt.Errorf("Eventing.Producer isn't alive")
+}
+
 // GetDebuggerURL returns the v8 debugger url for supplied appname
 func (s *SuperSupervisor) GetDebuggerURL(appName string) string {
 	logPrefix := "SuperSupervisor::GetDebuggerURL"
@@ -125,6 +143,14 @@ func (s *SuperSupervisor) GetLcbExceptionsStats(appName string) map[string]uint6
 	return nil
 }
 
+// GetWorkerStats returns stats of each worker of the app
+func (s *SuperSupervisor) GetWorkerStats(appName string) map[string]*common.WorkerStats {
+	if p, ok := s.runningProducers[appName]; ok {
+		return p.GetWorkerStats()
+	}
+	return nil
+}
+
 // GetSeqsProcessed returns vbucket specific sequence nos processed so far
 func (s *SuperSupervisor) GetSeqsProcessed(appName string) map[int]int64 {
 	if p, ok := s.runningProducers[appName]; ok {
@@ -175,6 +201,24 @@ func (s *SuperSupervisor) SignalStopDebugger(appName string) {
 	}
 }
 
+// SubscribeAppLog subscribes to application log lines written on this node
+func (s *SuperSupervisor) SubscribeAppLog(appName string) (int64, <-chan string, error) {
+	p, ok := s.runningProducers[appName]
+	if ok {
+		id, ch := p.SubscribeAppLog()
+		return id, ch, nil
+	}
+
+	return 0, nil, fmt.Errorf("Eventing.Producer isn't alive")
+}
+
+// UnsubscribeAppLog cancels subscription to application log lines
+func (s *SuperSupervisor) UnsubscribeAppLog(appName string, id int64) {
+	if p, ok := s.runningProducers[appName]; ok {
+		p.UnsubscribeAppLog(id)
+	}
+}
+
 // GetAppState returns current state of app
 func (s *SuperSupervisor) GetAppState(appName string) int8 {
 	switch s.appDeploymentStatus[appName] {
@@ -255,6 +299,14 @@ func (s *SuperSupervisor) InternalVbDistributionStats(appName string) map[string
 	return nil
 }
 
+// IsProcessingPaused reports whether processing of events has been paused for the app on current node
+func (s *SuperSupervisor) IsProcessingPaused(appName string) bool {
+	if p, ok := s.runningProducers[appName]; ok {
+		return p.IsProcessingPaused()
+	}
+	return false
+}
+
 // VbDistributionStatsFromMetadata returns vbucket distribution across eventing nodes from metadata bucket
 func (s *SuperSupervisor) VbDistributionStatsFromMetadata(appName string) map[string]map[string]string {
 	p, ok := s.runningProducers[appName]
@@ -318,6 +370,14 @@ func (s *SuperSupervisor) RebalanceStatus() bool {
 	return false
 }
 
+// GetRebalanceStatus reports back status of rebalance for the app on current node
+func (s *SuperSupervisor) GetRebalanceStatus(appName string) bool {
+	if p, ok := s.runningProducers[appName]; ok {
+		return p.RebalanceStatus()
+	}
+	return false
+}
+
 // BootstrapAppList returns list of apps undergoing bootstrap
 func (s *SuperSupervisor) BootstrapAppList() map[string]string {
 	bootstrappingApps := make(map[string]string)
@@ -33,6 +33,22 @@ ErrorHandler.prototype.getErrorName = function(errCode) {
     throw `${errCode} not received as part of handshake`;
 };
 
+// Returns name and runtime info of an error response body, which is either
+// a v2 envelope or in v1 format, as per error_format in config.
+ErrorHandler.parseErrorBody = function(data) {
+    if (data && data.error && data.error.code) {
+        return {
+            name: data.error.code,
+            runtimeInfo: data.error.message
+        };
+    }
+
+    return {
+        name: data ? data.name : undefined,
+        runtimeInfo: data ? data.runtime_info : undefined
+    };
+};
+
 // Creates and returns an ErrorMessage instance.
 ErrorHandler.prototype.createErrorMsg = function(errCode, details) {
     console.assert(this.errors[errCode], 'Error code ' + errCode + ' not defined');
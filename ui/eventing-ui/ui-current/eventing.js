@@ -151,12 +151,13 @@ angular.module('eventing', ['mnPluggableUiRegistry', 'ui.router', 'mnPoolDefault
                         showSuccessAlert(`${app.appname} deployed successfully!`);
                     })
                     .catch(function(errResponse) {
-                        if (errResponse.data && (errResponse.data.name === 'ERR_HANDLER_COMPILATION')) {
-                            var info = JSON.parse(errResponse.data.runtime_info);
+                        var errBody = ErrorHandler.parseErrorBody(errResponse.data);
+                        if (errBody.name === 'ERR_HANDLER_COMPILATION') {
+                            var info = JSON.parse(errBody.runtimeInfo);
                             app.compilationInfo = info;
                             showErrorAlert(`Deployment failed: Syntax error (${info.line_number}, ${info.column_number}) - ${info.description}`);
                         } else {
-                            showErrorAlert(`Deployment failed: ${errResponse.data.runtime_info}`);
+                            showErrorAlert(`Deployment failed: ${errBody.runtimeInfo}`);
                         }
 
                         // Enable edit button as we got compilation info
@@ -0,0 +1,115 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/couchbase/eventing/logging"
+)
+
+// AppLogTimestampFormat is the layout of the timestamp every app log entry starts with
+const AppLogTimestampFormat = "2006-01-02T15:04:05.000-07:00"
+
+type appLogEntry struct {
+	ts    time.Time
+	lines []string
+}
+
+type appLogEntries []*appLogEntry
+
+func (e appLogEntries) Len() int           { return len(e) }
+func (e appLogEntries) Less(i, j int) bool { return e[i].ts.Before(e[j].ts) }
+func (e appLogEntries) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+// Groups app log lines into entries, lines not starting with a timestamp are continuation
+// of a multi-line log message and stay with the entry they belong to
+func groupAppLogEntries(lines []string) appLogEntries {
+	entries := make(appLogEntries, 0)
+
+	for _, line := range lines {
+		ts, err := time.Parse(AppLogTimestampFormat, strings.SplitN(line, " ", 2)[0])
+		if err != nil && len(entries) > 0 {
+			last := entries[len(entries)-1]
+			last.lines = append(last.lines, line)
+			continue
+		}
+
+		entries = append(entries, &appLogEntry{ts: ts, lines: []string{line}})
+	}
+
+	return entries
+}
+
+// Merges app logs from several eventing nodes by timestamp and returns
+// the last sz lines
+func mergeAppLogs(nodeLogs [][]string, sz int) []string {
+	entries := make(appLogEntries, 0)
+	for _, lines := range nodeLogs {
+		entries = append(entries, groupAppLogEntries(lines)...)
+	}
+
+	sort.Stable(entries)
+
+	merged := make([]string, 0)
+	for _, entry := range entries {
+		merged = append(merged, entry.lines...)
+	}
+
+	if len(merged) > sz {
+		merged = merged[len(merged)-sz:]
+	}
+	return merged
+}
+
+// GetAppLog fetches app log lines from all supplied eventing nodes and merges them by timestamp
+func GetAppLog(urlSuffix string, nodeAddrs []string, sz int) ([]string, error) {
+	netClient := NewClient(HTTPRequestTimeout)
+	nodeLogs := make([][]string, 0)
+
+	for _, nodeAddr := range nodeAddrs {
+		lines, err := getNodeAppLog(netClient, fmt.Sprintf("http://%s%s", nodeAddr, urlSuffix))
+		if err != nil {
+			return nil, err
+		}
+
+		nodeLogs = append(nodeLogs, lines)
+	}
+
+	return mergeAppLogs(nodeLogs, sz), nil
+}
+
+func getNodeAppLog(netClient *Client, endpointURL string) ([]string, error) {
+	logPrefix := "util::getNodeAppLog"
+
+	res, err := netClient.Get(endpointURL)
+	if err != nil {
+		logging.Errorf("%s Failed to gather app log from url: %rs, err: %v", logPrefix, endpointURL, err)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		logging.Errorf("%s Failed to gather app log from url: %rs, status: %v", logPrefix, endpointURL, res.Status)
+		return nil, fmt.Errorf("url: %s returned status: %v", endpointURL, res.Status)
+	}
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		logging.Errorf("%s Failed to read response body for app log from url: %rs, err: %v", logPrefix, endpointURL, err)
+		return nil, err
+	}
+
+	var lines []string
+	err = json.Unmarshal(buf, &lines)
+	if err != nil {
+		logging.Errorf("%s Failed to unmarshal app log from url: %rs, err: %v", logPrefix, endpointURL, err)
+		return nil, err
+	}
+
+	return lines, nil
+}
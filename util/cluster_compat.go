@@ -0,0 +1,19 @@
+package util
+
+// ns_server encodes cluster compatibility of a node as major << 16 | minor
+const clusterCompatMajorShift = 16
+
+func compatAtLeast(compat, major, minor int) bool {
+	return compat >= major<<clusterCompatMajorShift|minor
+}
+
+// ClusterCompatAtLeast reports whether every node of the cluster, including those being
+// added or failed over, runs major.minor or later
+func ClusterCompatAtLeast(auth, hostaddress string, major, minor int) (bool, error) {
+	cinfo, err := FetchNewClusterInfoCache(hostaddress)
+	if err != nil {
+		return false, err
+	}
+
+	return cinfo.ClusterCompatAtLeast(major, minor), nil
+}
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestCompatAtLeast(t *testing.T) {
+	tests := []struct {
+		name   string
+		compat int
+		major  int
+		minor  int
+		want   bool
+	}{
+		{"same version", 6<<16 | 6, 6, 6, true},
+		{"later minor", 6<<16 | 6, 6, 5, true},
+		{"earlier minor", 6<<16 | 5, 6, 6, false},
+		{"later major", 7<<16 | 0, 6, 6, true},
+		{"earlier major", 5<<16 | 5, 6, 0, false},
+		{"unknown", 0, 6, 6, false},
+	}
+
+	for _, test := range tests {
+		if got := compatAtLeast(test.compat, test.major, test.minor); got != test.want {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -54,6 +54,7 @@ type ClusterInfoCache struct {
 	failedNodes []couchbase.Node
 	addNodes    []couchbase.Node
 	version     uint64
+	compat      int // Lowest cluster compatibility of the nodes, major << 16 | minor
 }
 
 type NodeId int
@@ -125,6 +126,7 @@ func (c *ClusterInfoCache) Fetch() error {
 		var failedNodes []couchbase.Node
 		var addNodes []couchbase.Node
 		version := uint64(math.MaxUint64)
+		compat := math.MaxInt32
 		for _, n := range c.pool.Nodes {
 			if n.ClusterMembership == "active" {
 				nodes = append(nodes, n)
@@ -143,6 +145,9 @@ func (c *ClusterInfoCache) Fetch() error {
 			if v < version {
 				version = v
 			}
+			if n.ClusterCompatibility < compat {
+				compat = n.ClusterCompatibility
+			}
 		}
 		c.nodes = nodes
 		c.failedNodes = failedNodes
@@ -153,6 +158,11 @@ func (c *ClusterInfoCache) Fetch() error {
 			c.version = 0
 		}
 
+		c.compat = compat
+		if c.compat == math.MaxInt32 {
+			c.compat = 0
+		}
+
 		found := false
 		for _, node := range c.nodes {
 			if node.ThisNode {
@@ -226,6 +236,11 @@ func (c *ClusterInfoCache) GetClusterVersion() uint64 {
 	return c.version
 }
 
+// ClusterCompatAtLeast reports whether every node of the cluster runs major.minor or later
+func (c *ClusterInfoCache) ClusterCompatAtLeast(major, minor int) bool {
+	return compatAtLeast(c.compat, major, minor)
+}
+
 func (c *ClusterInfoCache) GetServerGroup(nid NodeId) string {
 
 	return c.node2group[nid]
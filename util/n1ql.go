@@ -3,6 +3,9 @@ package util
 import (
 	"fmt"
 	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/expression"
@@ -10,11 +13,15 @@ import (
 )
 
 type queryStmt struct {
-	namedParams map[string]int
+	namedParams    map[string]int
+	subqueryParams map[string]int // Of subqueries in expressions, only reported as usage
+	keyspaces      map[string]int // Referenced by subqueries in expressions
 }
 
 type queryExpr struct {
-	namedParams map[string]int
+	namedParams    map[string]int
+	subqueryParams map[string]int
+	keyspaces      map[string]int
 }
 
 type ParseInfo struct {
@@ -102,6 +109,327 @@ func GetNamedParams(query string) (info *NamedParamsInfo) {
 	return
 }
 
+// N1QLUsage describes what an inline N1QL query does, for reporting its usage
+type N1QLUsage struct {
+	IsValid     bool     `json:"is_valid"`
+	Info        string   `json:"info,omitempty"`
+	Type        string   `json:"type"`
+	IsDmlQuery  bool     `json:"is_dml_query"`
+	Target      string   `json:"target_keyspace,omitempty"` // Keyspace mutated by DML
+	Keyspaces   []string `json:"keyspaces"`
+	NamedParams []string `json:"named_params"`
+}
+
+func GetN1QLUsage(query string) (usage *N1QLUsage) {
+	usage = &N1QLUsage{Keyspaces: make([]string, 0), NamedParams: make([]string, 0)}
+
+	parseInfo, alg := Parse(query)
+	usage.IsValid = parseInfo.IsValid
+	usage.Info = parseInfo.Info
+	usage.IsDmlQuery = parseInfo.IsDmlQuery
+	usage.Target = parseInfo.KeyspaceName
+	if !usage.IsValid {
+		return
+	}
+
+	usage.Type = statementType(alg)
+
+	qs := queryStmt{}
+	_, err := alg.Accept(&qs)
+	if err != nil {
+		usage.IsValid = false
+		usage.Info = fmt.Sprintf("%v", err)
+		return
+	}
+
+	if qs.keyspaces == nil {
+		qs.keyspaces = make(map[string]int)
+	}
+	statementKeyspaces(alg, qs.keyspaces)
+
+	for keyspace := range qs.keyspaces {
+		usage.Keyspaces = append(usage.Keyspaces, keyspace)
+	}
+	sort.Strings(usage.Keyspaces)
+
+	for namedParam := range qs.namedParams {
+		usage.NamedParams = append(usage.NamedParams, namedParam)
+	}
+	for namedParam := range qs.subqueryParams {
+		if _, ok := qs.namedParams[namedParam]; !ok {
+			usage.NamedParams = append(usage.NamedParams, namedParam)
+		}
+	}
+	sort.Strings(usage.NamedParams)
+
+	return
+}
+
+func statementType(alg algebra.Statement) string {
+	switch alg.(type) {
+	case *algebra.Select:
+		return "SELECT"
+	case *algebra.Insert:
+		return "INSERT"
+	case *algebra.Upsert:
+		return "UPSERT"
+	case *algebra.Delete:
+		return "DELETE"
+	case *algebra.Update:
+		return "UPDATE"
+	case *algebra.Merge:
+		return "MERGE"
+	case *algebra.CreatePrimaryIndex:
+		return "CREATE PRIMARY INDEX"
+	case *algebra.CreateIndex:
+		return "CREATE INDEX"
+	case *algebra.DropIndex:
+		return "DROP INDEX"
+	case *algebra.AlterIndex:
+		return "ALTER INDEX"
+	case *algebra.BuildIndexes:
+		return "BUILD INDEX"
+	case *algebra.GrantRole:
+		return "GRANT"
+	case *algebra.RevokeRole:
+		return "REVOKE"
+	case *algebra.Explain:
+		return "EXPLAIN"
+	case *algebra.Prepare:
+		return "PREPARE"
+	case *algebra.Execute:
+		return "EXECUTE"
+	case *algebra.InferKeyspace:
+		return "INFER"
+	default:
+		return "OTHER"
+	}
+}
+
+// Adds keyspaces which a statement reads from or writes to, other than those of
+// subqueries in its expressions, which are collected by the visitors
+func statementKeyspaces(alg algebra.Statement, keyspaces map[string]int) {
+	switch stmt := alg.(type) {
+	case *algebra.Select:
+		subresultKeyspaces(stmt.Subresult(), keyspaces)
+
+	case *algebra.Insert:
+		keyspaces[stmt.KeyspaceRef().Keyspace()] = 1
+		if stmt.Select() != nil {
+			statementKeyspaces(stmt.Select(), keyspaces)
+		}
+
+	case *algebra.Upsert:
+		keyspaces[stmt.KeyspaceRef().Keyspace()] = 1
+		if stmt.Select() != nil {
+			statementKeyspaces(stmt.Select(), keyspaces)
+		}
+
+	case *algebra.Delete:
+		keyspaces[stmt.KeyspaceRef().Keyspace()] = 1
+
+	case *algebra.Update:
+		keyspaces[stmt.KeyspaceRef().Keyspace()] = 1
+
+	case *algebra.Merge:
+		keyspaces[stmt.KeyspaceRef().Keyspace()] = 1
+		if source := stmt.Source(); source != nil {
+			if source.From() != nil {
+				fromTermKeyspaces(source.From(), keyspaces)
+			}
+			if source.Select() != nil {
+				statementKeyspaces(source.Select(), keyspaces)
+			}
+		}
+
+	case *algebra.Explain:
+		statementKeyspaces(stmt.Statement(), keyspaces)
+
+	default:
+		// Index statements
+		if indexStmt, ok := alg.(interface {
+			Keyspace() *algebra.KeyspaceRef
+		}); ok {
+			keyspaces[indexStmt.Keyspace().Keyspace()] = 1
+		}
+	}
+}
+
+func subresultKeyspaces(subresult algebra.Subresult, keyspaces map[string]int) {
+	if subselect, ok := subresult.(*algebra.Subselect); ok {
+		if subselect.From() != nil {
+			fromTermKeyspaces(subselect.From(), keyspaces)
+		}
+		return
+	}
+
+	// UNION, INTERSECT and EXCEPT
+	if setOp, ok := subresult.(interface {
+		First() algebra.Subresult
+		Second() algebra.Subresult
+	}); ok {
+		subresultKeyspaces(setOp.First(), keyspaces)
+		subresultKeyspaces(setOp.Second(), keyspaces)
+	}
+}
+
+func fromTermKeyspaces(term algebra.FromTerm, keyspaces map[string]int) {
+	switch t := term.(type) {
+	case *algebra.KeyspaceTerm:
+		keyspaces[t.Keyspace()] = 1
+		return
+
+	case *algebra.SubqueryTerm:
+		statementKeyspaces(t.Subquery(), keyspaces)
+		return
+	}
+
+	// Joins, nests and unnests
+	if joined, ok := term.(interface {
+		Left() algebra.FromTerm
+	}); ok {
+		fromTermKeyspaces(joined.Left(), keyspaces)
+	}
+
+	if joined, ok := term.(interface {
+		Right() *algebra.KeyspaceTerm
+	}); ok {
+		fromTermKeyspaces(joined.Right(), keyspaces)
+	}
+}
+
+// N1QLStatement is an inline N1QL query found in handler code
+type N1QLStatement struct {
+	Query string `json:"query"`
+	Line  int    `json:"line_number"`
+}
+
+// Keywords which start an inline N1QL query, as recognised by the transpiler
+var n1qlKeywords = map[string]struct{}{
+	"alter": {}, "build": {}, "create": {}, "delete": {}, "drop": {}, "execute": {},
+	"explain": {}, "from": {}, "grant": {}, "infer": {}, "insert": {}, "merge": {},
+	"prepare": {}, "rename": {}, "revoke": {}, "select": {}, "update": {}, "upsert": {},
+}
+
+var n1qlFromClause = regexp.MustCompile(`(?i)\bfrom\b`)
+
+// JavaScript assignment or comparison operators, which never follow the keyword starting a query
+var jsAssignOrCompare = regexp.MustCompile(`^(=|!=|[-+*/%&|^<>]=|\*\*=|<<=|>>>?=)`)
+
+// Keywords after which a N1QL keyword is the name of a JavaScript variable or function
+var jsDeclarationKeywords = map[string]struct{}{
+	"const": {}, "function": {}, "let": {}, "var": {},
+}
+
+// ExtractN1QLStatements returns inline N1QL queries in handler code. Like the transpiler, a
+// query starts at a N1QL keyword outside of comments and string literals, and ends at ';'.
+// Keyword being declared, followed by ':' as an object key, or assigned to or compared is a
+// JavaScript identifier instead.
+func ExtractN1QLStatements(code string) []N1QLStatement {
+	var stmts []N1QLStatement
+
+	line := 1
+	prevWord := ""
+
+	for i := 0; i < len(code); {
+		ch := code[i]
+
+		switch {
+		case ch == '\n':
+			line++
+			i++
+
+		case strings.HasPrefix(code[i:], "//"):
+			end := strings.IndexByte(code[i:], '\n')
+			if end == -1 {
+				return stmts
+			}
+			i += end
+
+		case strings.HasPrefix(code[i:], "/*"):
+			end := strings.Index(code[i+2:], "*/")
+			if end == -1 {
+				return stmts
+			}
+			line += strings.Count(code[i:i+2+end], "\n")
+			i += 2 + end + 2
+			prevWord = ""
+
+		case ch == '"' || ch == '\'' || ch == '`':
+			j := i + 1
+			for j < len(code) && code[j] != ch {
+				if code[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(code) {
+				return stmts
+			}
+			line += strings.Count(code[i:j], "\n")
+			i = j + 1
+			prevWord = ""
+
+		case isIdentChar(ch):
+			j := i
+			for j < len(code) && isIdentChar(code[j]) {
+				j++
+			}
+			word := code[i:j]
+
+			_, isKeyword := n1qlKeywords[strings.ToLower(word)]
+			_, isDeclared := jsDeclarationKeywords[prevWord]
+			next := strings.TrimLeft(code[j:], " \t\r\n")
+			isQuery := isKeyword && j < len(code) && isSpace(code[j]) &&
+				(i == 0 || code[i-1] != '.') && !isDeclared &&
+				!strings.HasPrefix(next, ":") && !jsAssignOrCompare.MatchString(next)
+
+			if !isQuery {
+				prevWord = word
+				i = j
+				continue
+			}
+
+			end := strings.IndexByte(code[i:], ';')
+			if end == -1 {
+				end = len(code) - i
+			} else {
+				end++
+			}
+			query := code[i : i+end]
+
+			// JavaScript delete operator, transpiler leaves it as is
+			if strings.ToLower(word) == "delete" && !n1qlFromClause.MatchString(query) {
+				prevWord = word
+				i = j
+				continue
+			}
+
+			stmts = append(stmts, N1QLStatement{Query: query, Line: line})
+			line += strings.Count(query, "\n")
+			i += end
+			prevWord = ""
+
+		case isSpace(ch):
+			i++
+
+		default:
+			prevWord = ""
+			i++
+		}
+	}
+
+	return stmts
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch == '$' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
 func handleStmt(qs *queryStmt, expressions expression.Expressions) error {
 	if qs.namedParams == nil {
 		qs.namedParams = make(map[string]int)
@@ -117,6 +445,20 @@ func handleStmt(qs *queryStmt, expressions expression.Expressions) error {
 		for param := range qe.namedParams {
 			qs.namedParams[param] = 1
 		}
+
+		if len(qe.subqueryParams) > 0 && qs.subqueryParams == nil {
+			qs.subqueryParams = make(map[string]int)
+		}
+		for param := range qe.subqueryParams {
+			qs.subqueryParams[param] = 1
+		}
+
+		if len(qe.keyspaces) > 0 && qs.keyspaces == nil {
+			qs.keyspaces = make(map[string]int)
+		}
+		for keyspace := range qe.keyspaces {
+			qs.keyspaces[keyspace] = 1
+		}
 	}
 
 	return nil
@@ -431,6 +773,33 @@ func (qe *queryExpr) VisitFunction(expr expression.Function) (interface{}, error
 }
 
 func (qe *queryExpr) VisitSubquery(expr expression.Subquery) (interface{}, error) {
+	if subquery, ok := expr.(*algebra.Subquery); ok {
+		qs := queryStmt{}
+		_, err := subquery.Select().Accept(&qs)
+		if err != nil {
+			return expr, err
+		}
+
+		// Kept apart from named parameters of the statement, which the transpiler binds
+		if qe.subqueryParams == nil {
+			qe.subqueryParams = make(map[string]int)
+		}
+		for param := range qs.namedParams {
+			qe.subqueryParams[param] = 1
+		}
+		for param := range qs.subqueryParams {
+			qe.subqueryParams[param] = 1
+		}
+
+		if qe.keyspaces == nil {
+			qe.keyspaces = make(map[string]int)
+		}
+		for keyspace := range qs.keyspaces {
+			qe.keyspaces[keyspace] = 1
+		}
+		statementKeyspaces(subquery.Select(), qe.keyspaces)
+	}
+
 	err := handleExpr(qe, expr.Children())
 	return expr, err
 }
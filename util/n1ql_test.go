@@ -0,0 +1,117 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractN1QLStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []N1QLStatement
+	}{
+		{
+			name: "no queries",
+			code: "function OnUpdate(doc, meta) {\n  log(doc);\n}",
+			want: nil,
+		},
+		{
+			name: "assigned query",
+			code: "function OnUpdate(doc, meta) {\n  var res = SELECT * FROM `beer-sample`;\n}",
+			want: []N1QLStatement{{Query: "SELECT * FROM `beer-sample`;", Line: 2}},
+		},
+		{
+			name: "statement query",
+			code: "function OnDelete(meta) {\n\n  DELETE FROM dst WHERE id = $id;\n}",
+			want: []N1QLStatement{{Query: "DELETE FROM dst WHERE id = $id;", Line: 3}},
+		},
+		{
+			name: "multi line query",
+			code: "var r = SELECT name\n  FROM src;\nUPSERT INTO dst (KEY, VALUE) VALUES ('k', 1);",
+			want: []N1QLStatement{
+				{Query: "SELECT name\n  FROM src;", Line: 1},
+				{Query: "UPSERT INTO dst (KEY, VALUE) VALUES ('k', 1);", Line: 3},
+			},
+		},
+		{
+			name: "keywords in comments and strings",
+			code: "// select from comment;\n/* update\n x; */\nlog('select * from s;');\nlog(\"delete from s;\");",
+			want: nil,
+		},
+		{
+			name: "declared identifiers",
+			code: "const update = 1;\nlet from = 2;\nvar select = 3;\nfunction insert (doc) {}",
+			want: nil,
+		},
+		{
+			name: "assigned and compared identifiers",
+			code: "update = doc.count;\nif (from == 1) {}\nif (from === 2) {}\nupdate += 1;\nif (from != 3 && from >= 4) {}",
+			want: nil,
+		},
+		{
+			name: "object keys and properties",
+			code: "var o = {update : 1, from: 2};\nlog(o.update + o.from);",
+			want: nil,
+		},
+		{
+			name: "javascript delete operator",
+			code: "delete doc.field;\ndelete obj[key];",
+			want: nil,
+		},
+		{
+			name: "identifier then query on a later line",
+			code: "let from = 1;\nvar res = SELECT 1;",
+			want: []N1QLStatement{{Query: "SELECT 1;", Line: 2}},
+		},
+	}
+
+	for _, test := range tests {
+		if got := ExtractN1QLStatements(test.code); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v want %#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNamedParamsOfSubqueries(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		wantNamedParams []string
+		wantUsage       []string
+	}{
+		{
+			name:            "statement only",
+			query:           "SELECT * FROM `src` WHERE a = $a;",
+			wantNamedParams: []string{"a"},
+			wantUsage:       []string{"a"},
+		},
+		{
+			name:            "subquery",
+			query:           "SELECT * FROM `src` WHERE a = $a AND b IN (SELECT RAW c FROM `other` WHERE d = $d);",
+			wantNamedParams: []string{"a"},
+			wantUsage:       []string{"a", "d"},
+		},
+		{
+			name:            "same parameter in statement and subquery",
+			query:           "SELECT * FROM `src` WHERE a = $a AND b IN (SELECT RAW c FROM `other` WHERE d = $a);",
+			wantNamedParams: []string{"a"},
+			wantUsage:       []string{"a"},
+		},
+		{
+			name:      "only in subquery",
+			query:     "DELETE FROM `src` WHERE b IN (SELECT RAW c FROM `other` WHERE d = $d);",
+			wantUsage: []string{"d"},
+		},
+	}
+
+	for _, test := range tests {
+		if got := GetNamedParams(test.query).NamedParams; !reflect.DeepEqual(got, test.wantNamedParams) {
+			t.Errorf("%s: named params got %v want %v", test.name, got, test.wantNamedParams)
+		}
+
+		if got := GetN1QLUsage(test.query).NamedParams; !reflect.DeepEqual(got, test.wantUsage) {
+			t.Errorf("%s: usage got %v want %v", test.name, got, test.wantUsage)
+		}
+	}
+}
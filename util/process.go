@@ -0,0 +1,25 @@
+package util
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// ProcessAlive reports whether a process with the given pid is running
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	// On windows, looking up a process already fails if it doesn't exist
+	ps, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	return ps.Signal(syscall.Signal(0)) == nil
+}
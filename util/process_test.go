@@ -0,0 +1,24 @@
+package util
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProcessAlive(t *testing.T) {
+	tests := []struct {
+		name string
+		pid  int
+		want bool
+	}{
+		{"current process", os.Getpid(), true},
+		{"zero pid", 0, false},
+		{"negative pid", -1, false},
+	}
+
+	for _, test := range tests {
+		if got := ProcessAlive(test.pid); got != test.want {
+			t.Errorf("%s: got %v want %v", test.name, got, test.want)
+		}
+	}
+}
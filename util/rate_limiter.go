@@ -0,0 +1,114 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket, refilled at rate tokens per second and holding at
+// most a second worth of tokens. Tokens are taken without blocking and may run into
+// debt, callers are expected to hold off further events for as long as Delay reports.
+type RateLimiter struct {
+	sync.Mutex
+	rate   int // Non-positive rate means unlimited
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a token bucket allowing rate events per second, non-positive rate means unlimited
+func NewRateLimiter(rate int) *RateLimiter {
+	return &RateLimiter{
+		rate:   rate,
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+func (rl *RateLimiter) refill(now time.Time) {
+	rl.tokens += now.Sub(rl.last).Seconds() * float64(rl.rate)
+	if rl.tokens > float64(rl.rate) {
+		rl.tokens = float64(rl.rate)
+	}
+	rl.last = now
+}
+
+// Time it takes to pay off the debt, if any
+func (rl *RateLimiter) debt() time.Duration {
+	if rl.rate <= 0 || rl.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-rl.tokens / float64(rl.rate) * float64(time.Second))
+}
+
+// SetRate updates the rate. Debt is rescaled to the new rate, so that callers already
+// held off are held off for as long as before.
+func (rl *RateLimiter) SetRate(rate int) {
+	rl.Lock()
+	defer rl.Unlock()
+	rl.setRate(rate, time.Now())
+}
+
+func (rl *RateLimiter) setRate(rate int, now time.Time) {
+	if rate == rl.rate {
+		return
+	}
+
+	if rl.rate > 0 {
+		rl.refill(now)
+	}
+
+	switch {
+	case rate <= 0:
+		rl.tokens = 0
+	case rl.rate <= 0:
+		rl.tokens = float64(rate)
+	case rl.tokens < 0:
+		rl.tokens = rl.tokens * float64(rate) / float64(rl.rate)
+	case rl.tokens > float64(rate):
+		rl.tokens = float64(rate)
+	}
+
+	rl.rate = rate
+	rl.last = now
+}
+
+// Rate returns the current rate
+func (rl *RateLimiter) Rate() int {
+	rl.Lock()
+	defer rl.Unlock()
+	return rl.rate
+}
+
+// Take takes n tokens without blocking and returns how much longer callers are held off because of it
+func (rl *RateLimiter) Take(n int) time.Duration {
+	rl.Lock()
+	defer rl.Unlock()
+	return rl.take(n, time.Now())
+}
+
+func (rl *RateLimiter) take(n int, now time.Time) time.Duration {
+	if rl.rate <= 0 {
+		return 0
+	}
+
+	rl.refill(now)
+	before := rl.debt()
+	rl.tokens -= float64(n)
+	return rl.debt() - before
+}
+
+// Delay returns how long callers have to hold off events till the bucket is out of debt
+func (rl *RateLimiter) Delay() time.Duration {
+	rl.Lock()
+	defer rl.Unlock()
+	return rl.delay(time.Now())
+}
+
+func (rl *RateLimiter) delay(now time.Time) time.Duration {
+	if rl.rate <= 0 {
+		return 0
+	}
+
+	rl.refill(now)
+	return rl.debt()
+}
@@ -0,0 +1,153 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterTake(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type take struct {
+		at   time.Duration // Since start
+		n    int
+		want time.Duration // Added to the time callers are held off
+	}
+
+	tests := []struct {
+		name      string
+		rate      int
+		takes     []take
+		delayAt   time.Duration
+		wantDelay time.Duration
+	}{
+		{
+			name:  "unlimited",
+			rate:  0,
+			takes: []take{{0, 1000, 0}, {0, 1000, 0}},
+		},
+		{
+			name:  "within burst",
+			rate:  10,
+			takes: []take{{0, 5, 0}, {0, 5, 0}},
+		},
+		{
+			name:      "beyond burst",
+			rate:      10,
+			takes:     []take{{0, 10, 0}, {0, 1, 100 * time.Millisecond}, {0, 2, 200 * time.Millisecond}},
+			wantDelay: 300 * time.Millisecond,
+		},
+		{
+			name:      "debt paid off over time",
+			rate:      10,
+			takes:     []take{{0, 15, 500 * time.Millisecond}},
+			delayAt:   200 * time.Millisecond,
+			wantDelay: 300 * time.Millisecond,
+		},
+		{
+			name:    "debt fully paid off",
+			rate:    10,
+			takes:   []take{{0, 15, 500 * time.Millisecond}},
+			delayAt: time.Second,
+		},
+		{
+			name:      "refill capped at a second worth of tokens",
+			rate:      10,
+			takes:     []take{{0, 10, 0}, {time.Hour, 10, 0}, {time.Hour, 1, 100 * time.Millisecond}},
+			delayAt:   time.Hour,
+			wantDelay: 100 * time.Millisecond,
+		},
+		{
+			name:      "only the part of a take beyond existing debt counts",
+			rate:      10,
+			takes:     []take{{0, 12, 200 * time.Millisecond}, {100 * time.Millisecond, 1, 100 * time.Millisecond}},
+			delayAt:   100 * time.Millisecond,
+			wantDelay: 200 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		rl := NewRateLimiter(test.rate)
+		rl.last = start
+
+		for i, tk := range test.takes {
+			if got := rl.take(tk.n, start.Add(tk.at)); got != tk.want {
+				t.Errorf("%s: take %d got %v want %v", test.name, i, got, tk.want)
+			}
+		}
+
+		if got := rl.delay(start.Add(test.delayAt)); got != test.wantDelay {
+			t.Errorf("%s: delay got %v want %v", test.name, got, test.wantDelay)
+		}
+	}
+}
+
+func TestRateLimiterSetRate(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		rate      int
+		taken     int
+		newRate   int
+		wantDelay time.Duration
+		wantTake  time.Duration // Of one more token right after the rate change
+	}{
+		{
+			name:      "debt held as long after lowering rate",
+			rate:      1000,
+			taken:     1500,
+			newRate:   1,
+			wantDelay: 500 * time.Millisecond,
+			wantTake:  time.Second,
+		},
+		{
+			name:      "debt held as long after raising rate",
+			rate:      10,
+			taken:     15,
+			newRate:   100,
+			wantDelay: 500 * time.Millisecond,
+			wantTake:  10 * time.Millisecond,
+		},
+		{
+			name:     "tokens capped at new rate",
+			rate:     100,
+			newRate:  10,
+			taken:    0,
+			wantTake: 0,
+		},
+		{
+			name:    "debt forgiven when unlimited",
+			rate:    10,
+			taken:   20,
+			newRate: 0,
+		},
+		{
+			name:     "full bucket once limited",
+			rate:     0,
+			taken:    20,
+			newRate:  10,
+			wantTake: 0,
+		},
+	}
+
+	for _, test := range tests {
+		rl := NewRateLimiter(test.rate)
+		rl.last = start
+
+		rl.take(test.taken, start)
+		rl.setRate(test.newRate, start)
+
+		if got := rl.Rate(); got != test.newRate {
+			t.Errorf("%s: rate got %d want %d", test.name, got, test.newRate)
+		}
+
+		if got := rl.delay(start); got != test.wantDelay {
+			t.Errorf("%s: delay got %v want %v", test.name, got, test.wantDelay)
+		}
+
+		if got := rl.take(1, start); got != test.wantTake {
+			t.Errorf("%s: take got %v want %v", test.name, got, test.wantTake)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/value"
+)
+
+// SourceFilter is a N1QL WHERE-style expression over fields of a document, e.g. type = "order".
+// It isn't safe for concurrent use.
+type SourceFilter struct {
+	expr    expression.Expression
+	context expression.Context
+}
+
+// NewSourceFilter parses filter, returning nil filter for an empty one
+func NewSourceFilter(filter string) (*SourceFilter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+
+	expr, err := n1ql.ParseExpression(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q, err: %v", filter, err)
+	}
+
+	return &SourceFilter{
+		expr:    expr,
+		context: expression.NewIndexContext(),
+	}, nil
+}
+
+// Matches reports whether JSON document doc satisfies the filter, nil filter matches every
+// document. Documents the expression fails to evaluate against don't match.
+func (f *SourceFilter) Matches(doc []byte) bool {
+	if f == nil {
+		return true
+	}
+
+	result, err := f.expr.Evaluate(value.NewValue(doc), f.context)
+	if err != nil {
+		return false
+	}
+
+	return result.Truth()
+}
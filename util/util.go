@@ -46,6 +46,12 @@ func (s Uint16Slice) Len() int           { return len(s) }
 func (s Uint16Slice) Less(i, j int) bool { return s[i] < s[j] }
 func (s Uint16Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
+type Uint64Slice []uint64
+
+func (s Uint64Slice) Len() int           { return len(s) }
+func (s Uint64Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s Uint64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
 type Config map[string]interface{}
 
 type ConfigHolder struct {
@@ -508,6 +514,11 @@ func MetakvGet(path string) ([]byte, error) {
 	return data, err
 }
 
+// Revision can be passed to MetakvSet or MetaKvDelete to make them conditional
+func MetakvGetWithRev(path string) ([]byte, interface{}, error) {
+	return metakv.Get(path)
+}
+
 func MetakvSet(path string, value []byte, rev interface{}) error {
 	return metakv.Set(path, value, rev)
 }
@@ -40,7 +40,13 @@ enum v8_worker_opcode {
   V8_Worker_Opcode_Unknown
 };
 
-enum dcp_opcode { oDelete, oMutation, DCP_Opcode_Unknown };
+enum dcp_opcode {
+  oDelete,
+  oMutation,
+  oFiltered,
+  oExpiry,
+  DCP_Opcode_Unknown
+};
 
 enum app_worker_setting_opcode {
   oLogLevel,
@@ -133,6 +133,8 @@ extern std::atomic<int64_t> on_update_success;
 extern std::atomic<int64_t> on_update_failure;
 extern std::atomic<int64_t> on_delete_success;
 extern std::atomic<int64_t> on_delete_failure;
+extern std::atomic<int64_t> on_expiry_success;
+extern std::atomic<int64_t> on_expiry_failure;
 
 extern std::atomic<int64_t> doc_timer_create_failure;
 
@@ -141,6 +143,7 @@ extern std::atomic<int64_t> messages_processed_counter;
 // DCP or Timer event counter
 extern std::atomic<int64_t> cron_timer_msg_counter;
 extern std::atomic<int64_t> dcp_delete_msg_counter;
+extern std::atomic<int64_t> dcp_expiry_msg_counter;
 extern std::atomic<int64_t> dcp_mutation_msg_counter;
 extern std::atomic<int64_t> doc_timer_msg_counter;
 
@@ -186,8 +189,10 @@ public:
   void Checkpoint();
   void RouteMessage();
 
-  int SendUpdate(std::string value, std::string meta, std::string doc_type);
+  int SendUpdate(std::string value, std::string meta);
   int SendDelete(std::string meta);
+  int SendExpiry(std::string meta);
+  void UpdateSeqNo(std::string meta);
   void SendDocTimer(std::string callback_fn, std::string doc_id,
                     std::string timer_ts, int32_t partition);
   void SendCronTimer(std::string cron_cb_fns, std::string timer_ts,
@@ -214,6 +219,7 @@ public:
 
   v8::Persistent<v8::Function> on_update_;
   v8::Persistent<v8::Function> on_delete_;
+  v8::Persistent<v8::Function> on_expiry_;
 
   v8::Global<v8::ObjectTemplate> worker_template;
 
@@ -272,6 +278,11 @@ private:
   vb_seq_map_t vb_seq;
 
   bool ExecuteScript(v8::Local<v8::String> script);
+  int SendMetaEvent(const std::string &meta,
+                    v8::Persistent<v8::Function> &handler,
+                    const char *handler_name, int fail_code,
+                    std::atomic<int64_t> &success,
+                    std::atomic<int64_t> &failure);
   std::list<Bucket *> bucket_handles;
   N1QL *n1ql_handle;
   std::string last_exception;
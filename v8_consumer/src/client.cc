@@ -23,6 +23,7 @@ std::atomic<int64_t> e_v8_worker_lost = {0};
 
 std::atomic<int64_t> cron_timer_events_lost = {0};
 std::atomic<int64_t> delete_events_lost = {0};
+std::atomic<int64_t> expiry_events_lost = {0};
 std::atomic<int64_t> doc_timer_events_lost = {0};
 std::atomic<int64_t> mutation_events_lost = {0};
 
@@ -513,6 +514,7 @@ void AppWorker::RouteMessageWithResponse(header_t *parsed_header,
       fstats << R"("debugger_events_lost": )" << e_debugger_lost << ",";
       fstats << R"("mutation_events_lost": )" << mutation_events_lost << ",";
       fstats << R"("delete_events_lost": )" << delete_events_lost << ",";
+      fstats << R"("expiry_events_lost": )" << expiry_events_lost << ",";
       fstats << R"("cron_timer_events_lost": )" << cron_timer_events_lost
              << ",";
       fstats << R"("doc_timer_events_lost": )" << doc_timer_events_lost << ",";
@@ -531,11 +533,14 @@ void AppWorker::RouteMessageWithResponse(header_t *parsed_header,
       estats << on_update_success << R"(, "on_update_failure":)";
       estats << on_update_failure << R"(, "on_delete_success":)";
       estats << on_delete_success << R"(, "on_delete_failure":)";
-      estats << on_delete_failure << R"(, "doc_timer_create_failure":)";
+      estats << on_delete_failure << R"(, "on_expiry_success":)";
+      estats << on_expiry_success << R"(, "on_expiry_failure":)";
+      estats << on_expiry_failure << R"(, "doc_timer_create_failure":)";
       estats << doc_timer_create_failure << R"(, "messages_parsed":)";
       estats << messages_parsed << R"(, "cron_timer_msg_counter":)";
       estats << cron_timer_msg_counter << R"(, "dcp_delete_msg_counter":)";
-      estats << dcp_delete_msg_counter << R"(, "dcp_mutation_msg_counter":)";
+      estats << dcp_delete_msg_counter << R"(, "dcp_expiry_msg_counter":)";
+      estats << dcp_expiry_msg_counter << R"(, "dcp_mutation_msg_counter":)";
       estats << dcp_mutation_msg_counter << R"(, "doc_timer_msg_counter":)";
       estats << doc_timer_msg_counter
              << R"(, "enqueued_cron_timer_msg_counter":)";
@@ -648,6 +653,27 @@ void AppWorker::RouteMessageWithResponse(header_t *parsed_header,
         ++mutation_events_lost;
       }
       break;
+    case oExpiry:
+      worker_index = partition_thr_map[parsed_header->partition];
+      if (workers[worker_index] != nullptr) {
+        workers[worker_index]->Enqueue(parsed_header, parsed_message);
+      } else {
+        LOG(logError) << "Expiry event lost: worker " << worker_index
+                      << " is null" << std::endl;
+        ++expiry_events_lost;
+      }
+      break;
+    case oFiltered:
+      // Routed like mutations and deletions, so that seq nos of a vbucket stay
+      // in order
+      worker_index = partition_thr_map[parsed_header->partition];
+      if (workers[worker_index] != nullptr) {
+        workers[worker_index]->Enqueue(parsed_header, parsed_message);
+      } else {
+        LOG(logError) << "Filtered event lost: worker " << worker_index
+                      << " is null" << std::endl;
+      }
+      break;
     default:
       LOG(logError) << "Opcode " << getDCPOpcode(parsed_header->opcode)
                     << "is not implemented for eDCP" << std::endl;
@@ -56,6 +56,10 @@ dcp_opcode getDCPOpcode(int8_t opcode) {
     return oDelete;
   if (opcode == 2)
     return oMutation;
+  if (opcode == 3)
+    return oFiltered;
+  if (opcode == 4)
+    return oExpiry;
   return DCP_Opcode_Unknown;
 }
 
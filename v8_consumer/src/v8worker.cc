@@ -29,6 +29,8 @@ std::atomic<int64_t> on_update_success = {0};
 std::atomic<int64_t> on_update_failure = {0};
 std::atomic<int64_t> on_delete_success = {0};
 std::atomic<int64_t> on_delete_failure = {0};
+std::atomic<int64_t> on_expiry_success = {0};
+std::atomic<int64_t> on_expiry_failure = {0};
 
 std::atomic<int64_t> doc_timer_create_failure = {0};
 
@@ -36,6 +38,7 @@ std::atomic<int64_t> messages_processed_counter = {0};
 
 std::atomic<int64_t> cron_timer_msg_counter = {0};
 std::atomic<int64_t> dcp_delete_msg_counter = {0};
+std::atomic<int64_t> dcp_expiry_msg_counter = {0};
 std::atomic<int64_t> dcp_mutation_msg_counter = {0};
 std::atomic<int64_t> doc_timer_msg_counter = {0};
 
@@ -50,7 +53,8 @@ enum RETURN_CODE {
   kNoHandlersDefined,
   kFailedInitBucketHandle,
   kOnUpdateCallFail,
-  kOnDeleteCallFail
+  kOnDeleteCallFail,
+  kOnExpiryCallFail
 };
 
 const char *GetUsername(void *cookie, const char *host, const char *port,
@@ -397,6 +401,7 @@ V8Worker::~V8Worker() {
   context_.Reset();
   on_update_.Reset();
   on_delete_.Reset();
+  on_expiry_.Reset();
   delete conn_pool;
   delete n1ql_handle;
   delete settings;
@@ -497,11 +502,14 @@ int V8Worker::V8WorkerLoad(std::string script_to_execute) {
 
   v8::Local<v8::String> on_update = v8Str(GetIsolate(), "OnUpdate");
   v8::Local<v8::String> on_delete = v8Str(GetIsolate(), "OnDelete");
+  v8::Local<v8::String> on_expiry = v8Str(GetIsolate(), "OnExpiry");
 
   auto on_update_def = context->Global()->Get(on_update);
   auto on_delete_def = context->Global()->Get(on_delete);
+  auto on_expiry_def = context->Global()->Get(on_expiry);
 
-  if (!on_update_def->IsFunction() && !on_delete_def->IsFunction()) {
+  if (!on_update_def->IsFunction() && !on_delete_def->IsFunction() &&
+      !on_expiry_def->IsFunction()) {
     return kNoHandlersDefined;
   }
 
@@ -517,6 +525,12 @@ int V8Worker::V8WorkerLoad(std::string script_to_execute) {
     on_delete_.Reset(GetIsolate(), on_delete_fun);
   }
 
+  if (on_expiry_def->IsFunction()) {
+    v8::Local<v8::Function> on_expiry_fun =
+        v8::Local<v8::Function>::Cast(on_expiry_def);
+    on_expiry_.Reset(GetIsolate(), on_expiry_fun);
+  }
+
   if (bucket_handles.size() > 0) {
     auto bucket_handle = bucket_handles.begin();
 
@@ -829,7 +843,14 @@ void V8Worker::RouteMessage() {
         val.assign(payload->value()->str());
         metadata.assign(msg.header->metadata);
         dcp_mutation_msg_counter++;
-        this->SendUpdate(val, metadata, "json");
+        this->SendUpdate(val, metadata);
+        break;
+      case oFiltered:
+        this->UpdateSeqNo(msg.header->metadata);
+        break;
+      case oExpiry:
+        dcp_expiry_msg_counter++;
+        this->SendExpiry(msg.header->metadata);
         break;
       default:
         break;
@@ -940,8 +961,7 @@ void V8Worker::UpdateHistogram(Time::time_point start_time) {
   histogram->Add(ns.count() / 1000);
 }
 
-int V8Worker::SendUpdate(std::string value, std::string meta,
-                         std::string doc_type) {
+int V8Worker::SendUpdate(std::string value, std::string meta) {
   Time::time_point start_time = Time::now();
 
   v8::Locker locker(GetIsolate());
@@ -958,22 +978,24 @@ int V8Worker::SendUpdate(std::string value, std::string meta,
   v8::Context::Scope context_scope(context);
 
   LOG(logTrace) << "value: " << RU(value) << " meta: " << RU(meta)
-                << " doc_type: " << doc_type << std::endl;
+                << std::endl;
   v8::TryCatch try_catch(GetIsolate());
 
   v8::Handle<v8::Value> args[2];
-  if (doc_type.compare("json") == 0) {
+  args[1] =
+      v8::JSON::Parse(v8::String::NewFromUtf8(GetIsolate(), meta.c_str()));
+  auto meta_fields = args[1]->ToObject(context).ToLocalChecked();
+
+  // Binary documents arrive base64 encoded, and are passed on as strings
+  auto datatype_val = meta_fields->Get(v8Str(GetIsolate(), "datatype"));
+  if (datatype_val->StrictEquals(v8Str(GetIsolate(), "binary"))) {
+    args[0] = v8::String::NewFromUtf8(GetIsolate(), value.c_str());
+  } else {
     args[0] =
         v8::JSON::Parse(v8::String::NewFromUtf8(GetIsolate(), value.c_str()));
-  } else {
-    args[0] = v8::String::NewFromUtf8(GetIsolate(), value.c_str());
   }
 
-  args[1] =
-      v8::JSON::Parse(v8::String::NewFromUtf8(GetIsolate(), meta.c_str()));
-
   // Look for vbucket and corresponding seq no in metadata
-  auto meta_fields = args[1]->ToObject(context).ToLocalChecked();
   auto seq_str = v8Str(GetIsolate(), "seq");
   auto vb_str = v8Str(GetIsolate(), "vb");
 
@@ -1025,19 +1047,63 @@ int V8Worker::SendUpdate(std::string value, std::string meta,
   }
 }
 
-int V8Worker::SendDelete(std::string meta) {
-  Time::time_point start_time = Time::now();
-
+// Events filtered out by key_filters don't reach the handler, but their seq no
+// is still checkpointed
+void V8Worker::UpdateSeqNo(std::string meta) {
   v8::Locker locker(GetIsolate());
   v8::Isolate::Scope isolate_scope(GetIsolate());
   v8::HandleScope handle_scope(GetIsolate());
 
+  auto context = context_.Get(isolate_);
+  v8::Context::Scope context_scope(context);
+
+  auto meta_val =
+      v8::JSON::Parse(v8::String::NewFromUtf8(GetIsolate(), meta.c_str()));
+  auto meta_fields = meta_val->ToObject(context).ToLocalChecked();
+
+  auto seq_val = meta_fields->Get(v8Str(GetIsolate(), "seq"));
+  auto vb_val = meta_fields->Get(v8Str(GetIsolate(), "vb"));
+
+  if (seq_val->IsNumber() && vb_val->IsNumber()) {
+    vb_seq[vb_val->ToInteger()->Value()].get()->store(
+        seq_val->ToInteger()->Value(), std::memory_order_seq_cst);
+  }
+}
+
+int V8Worker::SendDelete(std::string meta) {
   if (on_delete_.IsEmpty()) {
-    UpdateHistogram(start_time);
+    UpdateHistogram(Time::now());
     on_delete_failure++;
     return kOnDeleteCallFail;
   }
 
+  return SendMetaEvent(meta, on_delete_, "OnDelete", kOnDeleteCallFail,
+                       on_delete_success, on_delete_failure);
+}
+
+int V8Worker::SendExpiry(std::string meta) {
+  // Handlers written before OnExpiry was introduced see expirations as deletes
+  if (on_expiry_.IsEmpty()) {
+    return SendDelete(meta);
+  }
+
+  return SendMetaEvent(meta, on_expiry_, "OnExpiry", kOnExpiryCallFail,
+                       on_expiry_success, on_expiry_failure);
+}
+
+// Invokes handler with meta as its only argument, shared by deletions and
+// expirations which carry no document body
+int V8Worker::SendMetaEvent(const std::string &meta,
+                            v8::Persistent<v8::Function> &handler,
+                            const char *handler_name, int fail_code,
+                            std::atomic<int64_t> &success,
+                            std::atomic<int64_t> &failure) {
+  Time::time_point start_time = Time::now();
+
+  v8::Locker locker(GetIsolate());
+  v8::Isolate::Scope isolate_scope(GetIsolate());
+  v8::HandleScope handle_scope(GetIsolate());
+
   auto context = context_.Get(isolate_);
   v8::Context::Scope context_scope(context);
 
@@ -1072,28 +1138,28 @@ int V8Worker::SendDelete(std::string meta) {
     }
 
     agent->PauseOnNextJavascriptStatement("Break on start");
-    if (DebugExecute("OnDelete", args, 1)) {
+    if (DebugExecute(handler_name, args, 1)) {
       return kSuccess;
     }
-    return kOnDeleteCallFail;
+    return fail_code;
   } else {
-    auto on_doc_delete = on_delete_.Get(isolate_);
+    auto handler_fn = handler.Get(isolate_);
 
     execute_flag = true;
     execute_start_time = Time::now();
-    on_doc_delete->Call(context->Global(), 1, args);
+    handler_fn->Call(context->Global(), 1, args);
     execute_flag = false;
 
     if (try_catch.HasCaught()) {
       std::cerr << "Exception message"
                 << ExceptionString(GetIsolate(), &try_catch) << std::endl;
       UpdateHistogram(start_time);
-      on_delete_failure++;
-      return kOnDeleteCallFail;
+      failure++;
+      return fail_code;
     }
 
     UpdateHistogram(start_time);
-    on_delete_success++;
+    success++;
     return kSuccess;
   }
 }